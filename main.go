@@ -20,6 +20,7 @@ func main() {
 	c, err := client.New(
 		client.WithTokenCredential(cred),
 		client.WithEndpoint("https://adeeventmapperprod.eastus2.kusto.windows.net"),
+		client.WithDefaultDatabase("eventmapper"),
 	)
 
 	if err != nil {
@@ -27,9 +28,15 @@ func main() {
 		return
 	}
 
-	err = c.Query(context.Background())
+	ds, err := c.Query(context.Background(), "", "logs_eventmapper_v2 | take 1")
 	if err != nil {
 		fmt.Printf("failed to query: %v\n", err)
 		return
 	}
+
+	for _, t := range ds.PrimaryResults() {
+		for _, row := range t.Rows {
+			fmt.Printf("%v\n", row.Values)
+		}
+	}
 }
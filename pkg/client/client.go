@@ -6,6 +6,10 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/crodriguezde/go-kusto/pkg/conn"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/frames"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/table"
 )
 
 type Client struct {
@@ -13,6 +17,10 @@ type Client struct {
 	http     *http.Client
 	endpoint string
 	conn     *conn.Conn
+	retry    *conn.RetryOptions
+	database string
+
+	connOptions []conn.Option
 }
 
 func New(options ...ClientOption) (*Client, error) {
@@ -32,16 +40,74 @@ func New(options ...ClientOption) (*Client, error) {
 		}
 	}
 
-	conn, err := conn.NewConn(c.endpoint, c.cred, c.http)
+	conn, err := conn.NewConn(c.endpoint, c.cred, c.http, c.connOptions...)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.retry != nil {
+		conn.SetRetryOptions(*c.retry)
+	}
+
 	c.conn = conn
 
 	return c, nil
 }
 
-func (c *Client) Query(ctx context.Context) error {
-	return c.conn.Query(ctx, "eventmapper", "logs_eventmapper_v2 | take 1\n", nil)
+// DefaultDatabase returns the database queries run against when none is given.
+func (c *Client) DefaultDatabase() string {
+	return c.database
+}
+
+// Ping checks that the endpoint is reachable and that the credential is authorized. As New does not send
+// any request, it can be used to check that the client is ready.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.conn.Ping(ctx)
+}
+
+// Query runs the KQL query against db, or against the default database when db is empty, and returns all
+// the tables of the response.
+func (c *Client) Query(ctx context.Context, db string, kql string, options ...query.QueryOption) (*table.Dataset, error) {
+	db, opts, err := c.prepare(db, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.conn.Query(ctx, db, kql, opts)
+}
+
+// QueryIter runs the KQL query against db, or against the default database when db is empty, and returns an
+// iterator over the response rows. The iterator is configured with query.IteratorOptions. The caller must
+// Close the iterator.
+func (c *Client) QueryIter(ctx context.Context, db string, kql string, options ...query.QueryOption) (*frames.RowIterator, error) {
+	db, opts, err := c.prepare(db, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.conn.QueryIter(ctx, db, kql, opts)
+}
+
+// Mgmt runs the management command against db, or against the default database when db is empty, and returns
+// the tables of the response.
+func (c *Client) Mgmt(ctx context.Context, db string, csl string, options ...query.QueryOption) (*table.Dataset, error) {
+	db, opts, err := c.prepare(db, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.conn.Mgmt(ctx, db, csl, opts)
+}
+
+// prepare returns the database a request runs against, defaulting to the default database, and its options.
+func (c *Client) prepare(db string, options []query.QueryOption) (string, *query.QueryOptions, error) {
+	if db == "" {
+		db = c.database
+	}
+	if db == "" {
+		return "", nil, errors.ErrWrapf(errors.ErrInvalidType, "no database given and no default database set")
+	}
+
+	opts, err := query.NewQueryOptions(options...)
+	if err != nil {
+		return "", nil, err
+	}
+	return db, opts, nil
 }
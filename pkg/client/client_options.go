@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/crodriguezde/go-kusto/pkg/conn"
 )
 
 type ClientOption func(c *Client)
@@ -20,8 +22,67 @@ func WithEndpoint(e string) ClientOption {
 	}
 }
 
+// WithHttp sets the http.Client used as the transport of the request pipeline.
 func WithHttp(http *http.Client) ClientOption {
 	return func(c *Client) {
 		c.http = http
 	}
 }
+
+// WithRetryOptions sets how failed requests are retried. conn.DefaultRetryOptions are used when not set.
+func WithRetryOptions(o conn.RetryOptions) ClientOption {
+	return func(c *Client) {
+		c.retry = &o
+	}
+}
+
+// WithPerCallPolicies adds policies to the request pipeline that run once per request, before it is retried.
+func WithPerCallPolicies(policies ...policy.Policy) ClientOption {
+	return func(c *Client) {
+		c.connOptions = append(c.connOptions, conn.WithPerCallPolicies(policies...))
+	}
+}
+
+// WithPerRetryPolicies adds policies to the request pipeline that run on every try of a request.
+func WithPerRetryPolicies(policies ...policy.Policy) ClientOption {
+	return func(c *Client) {
+		c.connOptions = append(c.connOptions, conn.WithPerRetryPolicies(policies...))
+	}
+}
+
+// WithLogging configures the logging of requests and responses.
+func WithLogging(o policy.LogOptions) ClientOption {
+	return func(c *Client) {
+		c.connOptions = append(c.connOptions, conn.WithLogging(o))
+	}
+}
+
+// WithTelemetry configures the User-Agent header sent with requests.
+func WithTelemetry(o policy.TelemetryOptions) ClientOption {
+	return func(c *Client) {
+		c.connOptions = append(c.connOptions, conn.WithTelemetry(o))
+	}
+}
+
+// WithCloudInfo sets the cloud information of the endpoint, skipping its discovery. It is needed for
+// clusters that do not publish their metadata, such as air-gapped clusters.
+func WithCloudInfo(ci conn.CloudInfo) ClientOption {
+	return func(c *Client) {
+		c.connOptions = append(c.connOptions, conn.WithCloudInfo(ci))
+	}
+}
+
+// WithDefaultDatabase sets the database queries and commands run against when they are given none.
+func WithDefaultDatabase(db string) ClientOption {
+	return func(c *Client) {
+		c.database = db
+	}
+}
+
+// WithoutAuth sends requests without a token, for local emulators such as Kustainer that accept
+// unauthenticated requests, over plain HTTP. No credential is needed.
+func WithoutAuth() ClientOption {
+	return func(c *Client) {
+		c.connOptions = append(c.connOptions, conn.WithoutAuth())
+	}
+}
@@ -1 +1,118 @@
 package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/crodriguezde/go-kusto/pkg/conn"
+	"github.com/crodriguezde/go-kusto/pkg/frames"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+)
+
+const v2Response = `[{"FrameType":"DataSetHeader","IsProgressive":true,"Version":"v2.0"},` +
+	`{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"x","ColumnType":"long"}]},` +
+	`{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[1],[2]]},` +
+	`{"FrameType":"TableProgress","TableId":1,"TableProgress":100},` +
+	`{"FrameType":"TableCompletion","TableId":1,"RowCount":2},` +
+	`{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}]`
+
+// newTestClient returns a client of a server answering queries with v2Response, and the messages it received.
+func newTestClient(t *testing.T, options ...ClientOption) (*Client, *[]conn.QueryMsg) {
+	t.Helper()
+
+	var msgs []conn.QueryMsg
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/rest/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var msg conn.QueryMsg
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		msgs = append(msgs, msg)
+		w.Write([]byte(v2Response))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(append([]ClientOption{
+		WithEndpoint(srv.URL),
+		WithTokenCredential(NewStaticTokenCredential("token")),
+		WithHttp(srv.Client()),
+	}, options...)...)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	return c, &msgs
+}
+
+func TestQueryDefaultDatabase(t *testing.T) {
+	c, msgs := newTestClient(t, WithDefaultDatabase("defaultdb"))
+
+	ds, err := c.Query(context.Background(), "", "T | take 2")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if len(ds.PrimaryResults()) != 1 || len(ds.PrimaryResults()[0].Rows) != 2 {
+		t.Errorf("unexpected dataset: %+v", ds.Tables)
+	}
+
+	if _, err := c.Query(context.Background(), "otherdb", "T", query.NoTruncation()); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if len(*msgs) != 2 {
+		t.Fatalf("server got %d requests, want 2", len(*msgs))
+	}
+	if m := (*msgs)[0]; m.DB != "defaultdb" || m.CSL != "T | take 2" {
+		t.Errorf("unexpected first request: %+v", m)
+	}
+	if m := (*msgs)[1]; m.DB != "otherdb" || m.Properties.Options[query.NoTruncationValue] != true {
+		t.Errorf("unexpected second request: %+v", m)
+	}
+}
+
+func TestQueryNoDatabase(t *testing.T) {
+	c, msgs := newTestClient(t)
+
+	if _, err := c.Query(context.Background(), "", "T"); err == nil {
+		t.Errorf("Query() returned no error without database")
+	}
+	if len(*msgs) != 0 {
+		t.Errorf("server got %d requests, want 0", len(*msgs))
+	}
+}
+
+func TestQueryIterOptions(t *testing.T) {
+	c, _ := newTestClient(t, WithDefaultDatabase("db"))
+
+	var (
+		rows     int
+		progress []float64
+	)
+	it, err := c.QueryIter(context.Background(), "", "T", query.IteratorOptions(frames.WithProgress(func(p frames.Progress) {
+		progress = append(progress, p.Percentage)
+	})))
+	if err != nil {
+		t.Fatalf("QueryIter() returned error: %v", err)
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if it.Row() != nil {
+			rows++
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if rows != 2 {
+		t.Errorf("iterated over %d rows, want 2", rows)
+	}
+	if len(progress) != 1 || progress[0] != 100 {
+		t.Errorf("progress = %v, want [100]", progress)
+	}
+}
@@ -0,0 +1,344 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
+// Keywords of a connection string, as written by ConnectionString.String.
+const (
+	dataSourceKeyword          = "Data Source"
+	initialCatalogKeyword      = "Initial Catalog"
+	federatedSecurityKeyword   = "AAD Federated Security"
+	applicationClientIDKeyword = "Application Client Id"
+	applicationKeyKeyword      = "Application Key"
+	authorityIDKeyword         = "Authority Id"
+	userTokenKeyword           = "User Token"
+	applicationTokenKeyword    = "Application Token"
+)
+
+// defaultAuthorityID is the tenant applications authenticate against when the connection string has no Authority Id.
+const defaultAuthorityID = "organizations"
+
+// keywords maps the keywords of a connection string and their aliases, lower cased and without spaces,
+// to the keyword they stand for.
+var keywords = map[string]string{
+	"datasource":           dataSourceKeyword,
+	"addr":                 dataSourceKeyword,
+	"address":              dataSourceKeyword,
+	"networkaddress":       dataSourceKeyword,
+	"server":               dataSourceKeyword,
+	"initialcatalog":       initialCatalogKeyword,
+	"database":             initialCatalogKeyword,
+	"aadfederatedsecurity": federatedSecurityKeyword,
+	"federatedsecurity":    federatedSecurityKeyword,
+	"fed":                  federatedSecurityKeyword,
+	"applicationclientid":  applicationClientIDKeyword,
+	"appclientid":          applicationClientIDKeyword,
+	"applicationkey":       applicationKeyKeyword,
+	"appkey":               applicationKeyKeyword,
+	"authorityid":          authorityIDKeyword,
+	"authority":            authorityIDKeyword,
+	"tenantid":             authorityIDKeyword,
+	"usertoken":            userTokenKeyword,
+	"usrtoken":             userTokenKeyword,
+	"applicationtoken":     applicationTokenKeyword,
+	"apptoken":             applicationTokenKeyword,
+}
+
+// ConnectionString holds the settings of a Kusto connection string, such as
+// "Data Source=https://cluster.kusto.windows.net;Initial Catalog=db;AAD Federated Security=True".
+// It can be parsed with ParseConnectionString, or built with NewConnectionString and its With methods.
+type ConnectionString struct {
+	// DataSource is the URL of the cluster.
+	DataSource string
+	// InitialCatalog is the default database.
+	InitialCatalog string
+	// FederatedSecurity enables the authentication with Azure Active Directory.
+	FederatedSecurity bool
+	// ApplicationClientID and ApplicationKey authenticate an application against AuthorityID.
+	ApplicationClientID string
+	ApplicationKey      string
+	// AuthorityID is the tenant the application belongs to.
+	AuthorityID string
+	// UserToken is a bearer token authenticating a user.
+	UserToken string
+	// ApplicationToken is a bearer token authenticating an application.
+	ApplicationToken string
+}
+
+// NewConnectionString returns a ConnectionString for the cluster at dataSource.
+func NewConnectionString(dataSource string) *ConnectionString {
+	return &ConnectionString{DataSource: dataSource}
+}
+
+// WithDatabase sets the default database.
+func (cs *ConnectionString) WithDatabase(db string) *ConnectionString {
+	cs.InitialCatalog = db
+	return cs
+}
+
+// WithAppKey authenticates as the application clientID with its key, in the tenant authorityID.
+func (cs *ConnectionString) WithAppKey(clientID, key, authorityID string) *ConnectionString {
+	cs.FederatedSecurity = true
+	cs.ApplicationClientID = clientID
+	cs.ApplicationKey = key
+	cs.AuthorityID = authorityID
+	return cs
+}
+
+// WithUserToken authenticates with the bearer token of a user.
+func (cs *ConnectionString) WithUserToken(token string) *ConnectionString {
+	cs.FederatedSecurity = true
+	cs.UserToken = token
+	return cs
+}
+
+// WithApplicationToken authenticates with the bearer token of an application.
+func (cs *ConnectionString) WithApplicationToken(token string) *ConnectionString {
+	cs.FederatedSecurity = true
+	cs.ApplicationToken = token
+	return cs
+}
+
+// WithFederatedSecurity authenticates with the default Azure credential of the environment.
+func (cs *ConnectionString) WithFederatedSecurity() *ConnectionString {
+	cs.FederatedSecurity = true
+	return cs
+}
+
+// ParseConnectionString parses a connection string made of keyword=value pairs separated by semicolons.
+// Keywords are case and space insensitive, and values may be quoted with ' or ". A connection string made
+// of a URL alone is accepted as its Data Source.
+func ParseConnectionString(s string) (*ConnectionString, error) {
+	cs := &ConnectionString{}
+
+	pairs, err := splitConnectionString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, pair := range pairs {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			if i == 0 && len(pairs) == 1 {
+				cs.DataSource = strings.TrimSpace(pair)
+				continue
+			}
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "connection string has no value for %q", strings.TrimSpace(pair))
+		}
+
+		keyword, ok := keywords[strings.ToLower(strings.ReplaceAll(key, " ", ""))]
+		if !ok {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "connection string has unknown keyword %q", strings.TrimSpace(key))
+		}
+
+		val, err = unquote(strings.TrimSpace(val))
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "connection string has invalid value for %q", keyword)
+		}
+
+		if err := cs.set(keyword, val); err != nil {
+			return nil, err
+		}
+	}
+
+	if cs.DataSource == "" {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "connection string has no %s", dataSourceKeyword)
+	}
+
+	return cs, nil
+}
+
+// set sets the setting of keyword to val.
+func (cs *ConnectionString) set(keyword, val string) error {
+	switch keyword {
+	case dataSourceKeyword:
+		cs.DataSource = val
+	case initialCatalogKeyword:
+		cs.InitialCatalog = val
+	case federatedSecurityKeyword:
+		switch strings.ToLower(val) {
+		case "true", "yes":
+			cs.FederatedSecurity = true
+		case "false", "no":
+			cs.FederatedSecurity = false
+		default:
+			return errors.ErrWrapf(errors.ErrInvalidType, "connection string has invalid %s %q", keyword, val)
+		}
+	case applicationClientIDKeyword:
+		cs.ApplicationClientID = val
+	case applicationKeyKeyword:
+		cs.ApplicationKey = val
+	case authorityIDKeyword:
+		cs.AuthorityID = val
+	case userTokenKeyword:
+		cs.UserToken = val
+	case applicationTokenKeyword:
+		cs.ApplicationToken = val
+	}
+	return nil
+}
+
+// String returns the connection string, with the canonical keywords. It holds the secrets of cs.
+func (cs *ConnectionString) String() string {
+	var sb strings.Builder
+
+	add := func(keyword, val string) {
+		if val == "" {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(";")
+		}
+		sb.WriteString(keyword + "=" + quote(val))
+	}
+
+	add(dataSourceKeyword, cs.DataSource)
+	add(initialCatalogKeyword, cs.InitialCatalog)
+	if cs.FederatedSecurity {
+		add(federatedSecurityKeyword, "True")
+	}
+	add(applicationClientIDKeyword, cs.ApplicationClientID)
+	add(applicationKeyKeyword, cs.ApplicationKey)
+	add(authorityIDKeyword, cs.AuthorityID)
+	add(userTokenKeyword, cs.UserToken)
+	add(applicationTokenKeyword, cs.ApplicationToken)
+
+	return sb.String()
+}
+
+// Credential returns the credential the connection string authenticates with: the user or application token,
+// the application key, or the default Azure credential of the environment with federated security.
+func (cs *ConnectionString) Credential() (azcore.TokenCredential, error) {
+	switch {
+	case cs.UserToken != "":
+		return NewStaticTokenCredential(cs.UserToken), nil
+	case cs.ApplicationToken != "":
+		return NewStaticTokenCredential(cs.ApplicationToken), nil
+	case cs.ApplicationClientID != "" && cs.ApplicationKey != "":
+		authorityID := cs.AuthorityID
+		if authorityID == "" {
+			authorityID = defaultAuthorityID
+		}
+		cred, err := azidentity.NewClientSecretCredential(authorityID, cs.ApplicationClientID, cs.ApplicationKey, nil)
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "failed to create application key credential")
+		}
+		return cred, nil
+	case cs.ApplicationClientID != "":
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "connection string has %s but no %s", applicationClientIDKeyword, applicationKeyKeyword)
+	case cs.FederatedSecurity:
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "failed to create default credential")
+		}
+		return cred, nil
+	}
+	return nil, errors.ErrWrapf(errors.ErrInvalidType, "connection string has no authentication")
+}
+
+// Authenticated reports whether the connection string has settings authenticating the requests. Connection
+// strings without any, such as "Data Source=http://localhost:8080", are those of local emulators.
+func (cs *ConnectionString) Authenticated() bool {
+	return cs.FederatedSecurity || cs.UserToken != "" || cs.ApplicationToken != "" || cs.ApplicationClientID != ""
+}
+
+// NewFromConnectionString returns a Client for the cluster of the connection string s, authenticated with
+// its credential, and with its Initial Catalog as default database. The requests are not authenticated when
+// s has no authentication settings. options are applied after the settings of s.
+func NewFromConnectionString(s string, options ...ClientOption) (*Client, error) {
+	cs, err := ParseConnectionString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []ClientOption{
+		WithEndpoint(cs.DataSource),
+		WithDefaultDatabase(cs.InitialCatalog),
+	}
+
+	if cs.Authenticated() {
+		cred, err := cs.Credential()
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, WithTokenCredential(cred))
+	} else {
+		settings = append(settings, WithoutAuth())
+	}
+
+	return New(append(settings, options...)...)
+}
+
+// splitConnectionString splits s on the semicolons that are not quoted, dropping empty pairs. A quote only
+// starts a quoted value at the beginning of the value, and a doubled quote within it is an escaped quote.
+func splitConnectionString(s string) ([]string, error) {
+	var (
+		pairs      []string
+		quote      rune
+		start      int
+		inValue    bool
+		valueStart bool
+		escaped    bool
+	)
+
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				if i+1 < len(s) && rune(s[i+1]) == quote {
+					escaped = true
+				} else {
+					quote = 0
+				}
+			}
+		case r == ';':
+			if pair := s[start:i]; strings.TrimSpace(pair) != "" {
+				pairs = append(pairs, pair)
+			}
+			start = i + 1
+			inValue, valueStart = false, false
+		case r == '=' && !inValue:
+			inValue, valueStart = true, true
+		case valueStart && r != ' ':
+			if r == '"' || r == '\'' {
+				quote = r
+			}
+			valueStart = false
+		}
+	}
+	if quote != 0 {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "connection string has an unterminated quote")
+	}
+	if pair := s[start:]; strings.TrimSpace(pair) != "" {
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, nil
+}
+
+// unquote removes the quotes around s, unescaping the doubled quotes it holds.
+func unquote(s string) (string, error) {
+	if len(s) == 0 || (s[0] != '"' && s[0] != '\'') {
+		return s, nil
+	}
+	q := s[:1]
+	if len(s) < 2 || s[len(s)-1] != q[0] {
+		return "", errors.ErrWrapf(errors.ErrInvalidType, "unterminated quote")
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], q+q, q), nil
+}
+
+// quote quotes s when it holds characters that have a meaning in connection strings.
+func quote(s string) string {
+	if !strings.ContainsAny(s, ";\"' ") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    ConnectionString
+		wantErr bool
+	}{
+		{
+			name: "documented keywords",
+			s:    "Data Source=https://cluster.kusto.windows.net;Initial Catalog=db;AAD Federated Security=True;Application Client Id=app;Application Key=key;Authority Id=tenant",
+			want: ConnectionString{
+				DataSource:          "https://cluster.kusto.windows.net",
+				InitialCatalog:      "db",
+				FederatedSecurity:   true,
+				ApplicationClientID: "app",
+				ApplicationKey:      "key",
+				AuthorityID:         "tenant",
+			},
+		},
+		{
+			name: "aliases",
+			s:    "addr=https://cluster;database=db;fed=true;AppClientId=app;AppKey=key;TenantId=tenant",
+			want: ConnectionString{
+				DataSource:          "https://cluster",
+				InitialCatalog:      "db",
+				FederatedSecurity:   true,
+				ApplicationClientID: "app",
+				ApplicationKey:      "key",
+				AuthorityID:         "tenant",
+			},
+		},
+		{
+			name: "tokens",
+			s:    "Server=https://cluster; User Token=user ;AppToken=app;",
+			want: ConnectionString{DataSource: "https://cluster", UserToken: "user", ApplicationToken: "app"},
+		},
+		{
+			name: "url alone",
+			s:    "https://cluster.kusto.windows.net",
+			want: ConnectionString{DataSource: "https://cluster.kusto.windows.net"},
+		},
+		{
+			name: "quoted values",
+			s:    `Data Source=https://cluster;AppKey="a;b""c";Initial Catalog='it''s';Authority Id=o'brien`,
+			want: ConnectionString{DataSource: "https://cluster", ApplicationKey: `a;b"c`, InitialCatalog: "it's", AuthorityID: "o'brien"},
+		},
+		{name: "unknown keyword", s: "Data Source=https://cluster;Colour=blue", wantErr: true},
+		{name: "missing data source", s: "Initial Catalog=db", wantErr: true},
+		{name: "invalid bool", s: "Data Source=https://cluster;Fed=maybe", wantErr: true},
+		{name: "unterminated quote", s: `Data Source="https://cluster`, wantErr: true},
+		{name: "missing value", s: "Data Source=https://cluster;db", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseConnectionString(test.s)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ParseConnectionString() returned %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConnectionString() returned error: %v", err)
+			}
+			if *got != test.want {
+				t.Errorf("ParseConnectionString() = %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestConnectionStringBuilder(t *testing.T) {
+	cs := NewConnectionString("https://cluster").WithDatabase("my db").WithAppKey("app", `k;e"y`, "tenant")
+
+	want := `Data Source=https://cluster;Initial Catalog="my db";AAD Federated Security=True;Application Client Id=app;Application Key="k;e""y";Authority Id=tenant`
+	if got := cs.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+
+	parsed, err := ParseConnectionString(cs.String())
+	if err != nil {
+		t.Fatalf("ParseConnectionString() returned error: %v", err)
+	}
+	if *parsed != *cs {
+		t.Errorf("ParseConnectionString(String()) = %+v, want %+v", *parsed, *cs)
+	}
+}
+
+func TestConnectionStringRoundTrip(t *testing.T) {
+	secrets := []string{
+		`a";b`,
+		`a'";b`,
+		`"`,
+		`""`,
+		`;`,
+		`'a;b'`,
+		`a"";b"`,
+		`x y`,
+		`'`,
+	}
+
+	for _, secret := range secrets {
+		cs := NewConnectionString("https://cluster").WithDatabase(secret).WithAppKey("app", secret, "tenant")
+		cs.UserToken = secret
+
+		parsed, err := ParseConnectionString(cs.String())
+		if err != nil {
+			t.Errorf("ParseConnectionString(%s) returned error: %v", cs.String(), err)
+			continue
+		}
+		if *parsed != *cs {
+			t.Errorf("ParseConnectionString(%s) = %+v, want %+v", cs.String(), *parsed, *cs)
+		}
+	}
+
+	cs, err := ParseConnectionString(`Data Source=https://cluster;Application Key='a'';b'`)
+	if err != nil {
+		t.Fatalf("ParseConnectionString() with a single quoted value returned error: %v", err)
+	}
+	if cs.ApplicationKey != "a';b" {
+		t.Errorf("ApplicationKey = %q, want %q", cs.ApplicationKey, "a';b")
+	}
+
+	if _, err := ParseConnectionString(`Data Source=https://cluster;Application Key="a""`); err == nil {
+		t.Errorf("ParseConnectionString() with an escaped closing quote returned no error")
+	}
+}
+
+func TestConnectionStringCredential(t *testing.T) {
+	cred, err := NewConnectionString("https://cluster").WithUserToken("token").Credential()
+	if err != nil {
+		t.Fatalf("Credential() returned error: %v", err)
+	}
+	token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+	if err != nil || token.Token != "token" {
+		t.Errorf("GetToken() = %v, %v, want the user token", token.Token, err)
+	}
+
+	if _, err := NewConnectionString("https://cluster").WithAppKey("app", "key", "").Credential(); err != nil {
+		t.Errorf("Credential() with application key returned error: %v", err)
+	}
+
+	if _, err := (&ConnectionString{DataSource: "https://cluster", ApplicationClientID: "app"}).Credential(); err == nil {
+		t.Errorf("Credential() without application key returned no error")
+	}
+	if _, err := NewConnectionString("https://cluster").Credential(); err == nil {
+		t.Errorf("Credential() without authentication returned no error")
+	}
+}
+
+func TestNewFromConnectionString(t *testing.T) {
+	c, err := NewFromConnectionString("Data Source=https://cluster.kusto.windows.net;Initial Catalog=db;User Token=token")
+	if err != nil {
+		t.Fatalf("NewFromConnectionString() returned error: %v", err)
+	}
+	if c.endpoint != "https://cluster.kusto.windows.net" || c.DefaultDatabase() != "db" {
+		t.Errorf("unexpected client: endpoint %q, database %q", c.endpoint, c.DefaultDatabase())
+	}
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/crodriguezde/go-kusto/pkg/conn"
+)
+
+// StaticTokenCredential authenticates with a token obtained by other means, such as a test token or a token
+// of a connection string. It is defined by package conn, whose tests use it.
+type StaticTokenCredential = conn.StaticTokenCredential
+
+// NewStaticTokenCredential returns a StaticTokenCredential for token.
+func NewStaticTokenCredential(token string) StaticTokenCredential {
+	return conn.NewStaticTokenCredential(token)
+}
+
+// FuncCredential adapts a function to azcore.TokenCredential, to get tokens from a custom source.
+type FuncCredential func(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error)
+
+// GetToken implements azcore.TokenCredential.
+func (f FuncCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return f(ctx, options)
+}
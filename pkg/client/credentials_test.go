@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+func TestFuncCredential(t *testing.T) {
+	var scopes []string
+	c, _ := newTestClient(t, WithTokenCredential(FuncCredential(func(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+		scopes = options.Scopes
+		return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+	})))
+
+	if _, err := c.Query(context.Background(), "db", "T"); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if len(scopes) != 1 {
+		t.Errorf("GetToken() was called with scopes %v, want one scope", scopes)
+	}
+
+	// The bearer token policy does not wrap the errors of the credential, so only their message is kept.
+	c, _ = newTestClient(t, WithTokenCredential(FuncCredential(func(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+		return azcore.AccessToken{}, errors.New("no token")
+	})))
+	if _, err := c.Query(context.Background(), "db", "T"); err == nil || !strings.Contains(err.Error(), "no token") {
+		t.Errorf("Query() returned error %v, want the error of the credential", err)
+	}
+}
+
+func TestWithoutAuth(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if h := r.Header.Get("Authorization"); h != "" {
+			t.Errorf("request has Authorization header %q", h)
+		}
+		w.Write([]byte(v2Response))
+	}))
+	defer srv.Close()
+
+	c, err := NewFromConnectionString("Data Source=" + srv.URL + ";Initial Catalog=db")
+	if err != nil {
+		t.Fatalf("NewFromConnectionString() returned error: %v", err)
+	}
+
+	ds, err := c.Query(context.Background(), "", "T")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if len(ds.PrimaryResults()) != 1 {
+		t.Errorf("unexpected dataset: %+v", ds.Tables)
+	}
+	if len(paths) != 1 || paths[0] != "/v2/rest/query" {
+		t.Errorf("server got requests %v, want the query alone", paths)
+	}
+}
+
+func TestNewWithoutCredential(t *testing.T) {
+	if _, err := New(WithEndpoint("https://cluster.kusto.windows.net")); err == nil {
+		t.Errorf("New() without credential returned no error")
+	}
+	if _, err := New(WithEndpoint("http://localhost:8080"), WithoutAuth()); err != nil {
+		t.Errorf("New() with WithoutAuth returned error: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package conn
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/utils"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+// maxServerTimeout is the longest servertimeout derived from a context deadline, the maximum the service accepts.
+const maxServerTimeout = time.Hour
+
+// cancelTimeout bounds the time spent canceling a query on the service.
+const cancelTimeout = 30 * time.Second
+
+// withDeadline returns properties with the servertimeout request property set to the time left before the
+// deadline of ctx, so that the service stops the query when the caller gives up on it. properties is
+// returned as is when ctx has no deadline, or when servertimeout or norequesttimeout are set.
+func withDeadline(ctx context.Context, properties *query.RequestProperties) *query.RequestProperties {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return properties
+	}
+	if properties != nil {
+		if _, ok := properties.Options[query.ServerTimeoutValue]; ok {
+			return properties
+		}
+		if _, ok := properties.Options[query.NoRequestTimeoutValue]; ok {
+			return properties
+		}
+	}
+
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return properties
+	}
+	if timeout > maxServerTimeout {
+		timeout = maxServerTimeout
+	}
+
+	// Copy the options, as the caller may reuse them. The other properties are shared with the caller.
+	p := query.RequestProperties{}
+	if properties != nil {
+		p = *properties
+	}
+	p.Options = make(map[string]interface{}, len(p.Options)+1)
+	if properties != nil {
+		for k, v := range properties.Options {
+			p.Options[k] = v
+		}
+	}
+	p.Options[query.ServerTimeoutValue] = value.Timespan{Valid: true, Value: timeout}.Marshal()
+
+	return &p
+}
+
+// watchCancel cancels the query with the client request ID id running against db when ctx is done before
+// the returned stop function is called. The query is canceled in the background, without waiting for it.
+func (c *Conn) watchCancel(ctx context.Context, db string, id string) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.cancelQuery(db, id)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// cancelQuery runs `.cancel query` for the query with the client request ID id. Errors are ignored, as the
+// query may have completed in the meantime.
+func (c *Conn) cancelQuery(db string, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	_, _ = c.Mgmt(ctx, db, ".cancel query "+utils.QuoteString(id, false), nil)
+}
+
+// cancelBody is the body of a query response, that stops watching for the cancellation of the query once
+// it is read to the end or closed.
+type cancelBody struct {
+	io.ReadCloser
+	stop func()
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.stop()
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	b.stop()
+	return b.ReadCloser.Close()
+}
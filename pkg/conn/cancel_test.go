@@ -0,0 +1,148 @@
+package conn
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+func TestWithDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	p := withDeadline(ctx, nil)
+	timeout, ok := p.Options[query.ServerTimeoutValue].(string)
+	if !ok {
+		t.Fatalf("servertimeout is not set: %+v", p.Options)
+	}
+	var ts value.Timespan
+	if err := ts.Unmarshal(timeout); err != nil || ts.Value > 10*time.Minute || ts.Value < 9*time.Minute {
+		t.Errorf("servertimeout = %s, want about 10 minutes", timeout)
+	}
+
+	opts, err := query.NewQueryOptions(query.NoTruncation())
+	if err != nil {
+		t.Fatalf("NewQueryOptions() returned error: %v", err)
+	}
+	p = withDeadline(ctx, opts.RequestProperties)
+	if p.Options[query.NoTruncationValue] != true || p.Options[query.ServerTimeoutValue] == nil {
+		t.Errorf("unexpected options: %+v", p.Options)
+	}
+	if _, ok := opts.RequestProperties.Options[query.ServerTimeoutValue]; ok {
+		t.Errorf("withDeadline() modified the caller options")
+	}
+	if !reflect.DeepEqual(p.Parameters, opts.RequestProperties.Parameters) {
+		t.Errorf("Parameters = %v, want %v", p.Parameters, opts.RequestProperties.Parameters)
+	}
+	if p := withDeadline(ctx, nil); p.Parameters != nil {
+		t.Errorf("Parameters = %v, want nil", p.Parameters)
+	}
+
+	long, cancelLong := context.WithTimeout(context.Background(), 48*time.Hour)
+	defer cancelLong()
+	if got := withDeadline(long, nil).Options[query.ServerTimeoutValue]; got != "01:00:00" {
+		t.Errorf("servertimeout = %v, want the maximum of one hour", got)
+	}
+
+	opts, err = query.NewQueryOptions(query.ServerTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() returned error: %v", err)
+	}
+	if got := withDeadline(ctx, opts.RequestProperties).Options[query.ServerTimeoutValue]; got != "00:01:00" {
+		t.Errorf("servertimeout = %v, want the explicit timeout", got)
+	}
+
+	if got := withDeadline(context.Background(), nil); got != nil {
+		t.Errorf("withDeadline() without deadline = %+v, want nil", got)
+	}
+}
+
+// newCancelServer returns a server whose queries block until the client goes away, unless block is false,
+// and that sends the management commands it receives to cmds.
+func newCancelServer(t *testing.T, block bool) (*httptest.Server, chan string) {
+	t.Helper()
+
+	cmds := make(chan string, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc(metadataPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"AzureAD":{"KustoServiceResourceId":"https://kusto.kusto.windows.net"}}`))
+	})
+	mux.HandleFunc("/v2/rest/query", func(w http.ResponseWriter, r *http.Request) {
+		if block {
+			// The server only notices that the client went away once the request body is read.
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(emptyV2Response))
+	})
+	mux.HandleFunc("/v1/rest/mgmt", func(w http.ResponseWriter, r *http.Request) {
+		var msg QueryMsg
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		cmds <- msg.DB + ": " + msg.CSL
+		w.Write([]byte(`{"Tables":[]}`))
+	})
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, cmds
+}
+
+func TestQueryCanceled(t *testing.T) {
+	srv, cmds := newCancelServer(t, true)
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	opts, err := query.NewQueryOptions(query.ClientRequestID("request-id"))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if _, err := c.Query(ctx, "db", "T", opts); err == nil {
+		t.Fatalf("Query() returned no error")
+	}
+
+	select {
+	case cmd := <-cmds:
+		if cmd != `db: .cancel query "request-id"` {
+			t.Errorf("got command %q", cmd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the query was not canceled")
+	}
+}
+
+func TestQueryCompletedNotCanceled(t *testing.T) {
+	srv, cmds := newCancelServer(t, false)
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := c.Query(ctx, "db", "T", nil); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	cancel()
+
+	select {
+	case cmd := <-cmds:
+		t.Errorf("got command %q after the query completed", cmd)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,48 @@
+package conn
+
+import (
+	"context"
+
+	"github.com/crodriguezde/go-kusto/pkg/internal/cloudcache"
+)
+
+// DefaultCloudInfo is the cloud information of the Azure public cloud, used for endpoints that do not
+// publish their metadata.
+var DefaultCloudInfo = CloudInfo{
+	LoginEndpoint:          "https://login.microsoftonline.com",
+	LoginMfaRequired:       false,
+	KustoClientAppID:       "db662dc1-0cfe-4e1c-a843-19a68e65be58",
+	KustoClientRedirectURI: "https://microsoft/kustoclient",
+	KustoServiceResourceID: "https://kusto.kusto.windows.net",
+	FirstPartyAuthorityURL: "https://login.microsoftonline.com/f8cdef31-a31e-4b4a-93e4-5f571e91255a",
+}
+
+// CloudInfo returns the cloud information of the endpoint. It is the CloudInfo set with WithCloudInfo,
+// or the one published by the endpoint. It is DefaultCloudInfo with WithoutAuth, as the endpoint may not
+// publish its metadata.
+//
+// The published cloud information is queried once per process and host, and cached for the lifetime of the
+// process: changes to it are not seen by the Conns created afterwards. Only the kustotest servers reset the
+// cache of their host, when their cloud information is set.
+func (c *Conn) CloudInfo(ctx context.Context) (*CloudInfo, error) {
+	if c.cloudInfo != nil {
+		return c.cloudInfo, nil
+	}
+
+	if c.noAuth {
+		ci := DefaultCloudInfo
+		return &ci, nil
+	}
+
+	if ci, ok := cloudcache.Load(c.endpoint.Host); ok {
+		return ci.(*CloudInfo), nil
+	}
+
+	ci, err := c.QueryAzureMetadataEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Concurrent queries of the same host may both succeed; all of them use the first one stored.
+	return cloudcache.LoadOrStore(c.endpoint.Host, ci).(*CloudInfo), nil
+}
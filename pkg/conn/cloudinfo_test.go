@@ -0,0 +1,131 @@
+package conn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newMetadataServer returns a server answering metadata requests with status and body, and counting them.
+func newMetadataServer(t *testing.T, status int, body string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var count int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != metadataPath {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func TestCloudInfoCached(t *testing.T) {
+	srv, count := newMetadataServer(t, http.StatusOK,
+		`{"AzureAD":{"LoginEndpoint":"https://login.sovereign.cloud","KustoServiceResourceId":"https://kusto.sovereign.cloud"}}`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+			if err != nil {
+				t.Errorf("NewConn() returned error: %v", err)
+				return
+			}
+			if err := c.init(context.Background()); err != nil {
+				t.Errorf("init() returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c, err := NewConn(srv.URL+"/path", NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	if err := c.init(context.Background()); err != nil {
+		t.Fatalf("init() returned error: %v", err)
+	}
+	ci, err := c.CloudInfo(context.Background())
+	if err != nil {
+		t.Fatalf("CloudInfo() returned error: %v", err)
+	}
+	if ci.LoginEndpoint != "https://login.sovereign.cloud" {
+		t.Errorf("LoginEndpoint = %q", ci.LoginEndpoint)
+	}
+	if got := c.Scope(); len(got) != 1 || got[0] != "https://kusto.sovereign.cloud/.default" {
+		t.Errorf("Scope() = %v", got)
+	}
+
+	// Concurrent connections may query the endpoint before the first answer is cached, later ones must not.
+	before := atomic.LoadInt32(count)
+	c, err = NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	if err := c.init(context.Background()); err != nil {
+		t.Fatalf("init() returned error: %v", err)
+	}
+	if after := atomic.LoadInt32(count); after != before || after == 0 {
+		t.Errorf("metadata queried %d times, then %d times", before, after)
+	}
+}
+
+func TestCloudInfoNotFound(t *testing.T) {
+	srv, _ := newMetadataServer(t, http.StatusNotFound, "")
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	ci, err := c.CloudInfo(context.Background())
+	if err != nil {
+		t.Fatalf("CloudInfo() returned error: %v", err)
+	}
+	if *ci != DefaultCloudInfo {
+		t.Errorf("CloudInfo() = %+v, want DefaultCloudInfo", ci)
+	}
+}
+
+func TestCloudInfoError(t *testing.T) {
+	srv, _ := newMetadataServer(t, http.StatusInternalServerError, "")
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	if _, err := c.CloudInfo(context.Background()); err == nil {
+		t.Errorf("CloudInfo() returned no error")
+	}
+}
+
+func TestWithCloudInfo(t *testing.T) {
+	srv, count := newMetadataServer(t, http.StatusOK, `{"AzureAD":{}}`)
+
+	ci := CloudInfo{LoginEndpoint: "https://login.airgapped", KustoServiceResourceID: "https://kusto.airgapped"}
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client(), WithCloudInfo(ci))
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	if err := c.init(context.Background()); err != nil {
+		t.Fatalf("init() returned error: %v", err)
+	}
+	if *count != 0 {
+		t.Errorf("metadata queried %d times, want 0", *count)
+	}
+	if got := c.Scope(); len(got) != 1 || got[0] != "https://kusto.airgapped/.default" {
+		t.Errorf("Scope() = %v", got)
+	}
+	if c.clientOptions.Cloud.ActiveDirectoryAuthorityHost != "https://login.airgapped" {
+		t.Errorf("authority host = %q", c.clientOptions.Cloud.ActiveDirectoryAuthorityHost)
+	}
+}
@@ -10,18 +10,48 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
 	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/frames"
 	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/google/uuid"
 )
 
 var metadataPath = "/v1/rest/auth/metadata"
 
+// pingDB is the database Ping runs against, available on every cluster.
+const pingDB = "NetDefaultDB"
+
+// Module name and version reported in the User-Agent header by the telemetry policy.
+const (
+	moduleName    = "go-kusto"
+	moduleVersion = "v0.1.0"
+)
+
+// Headers used to trace requests in the `.show queries` output.
+const (
+	ClientRequestIdHeader = "x-ms-client-request-id"
+	ApplicationHeader     = "x-ms-app"
+	UserHeader            = "x-ms-user"
+)
+
+var (
+	defaultApplication = filepath.Base(os.Args[0])
+	defaultUser        = currentUser()
+)
+
 var bufferPool = sync.Pool{
 	New: func() interface{} {
 		return &bytes.Buffer{}
@@ -32,10 +62,25 @@ type Conn struct {
 	auth          azcore.TokenCredential
 	endpoint      *url.URL
 	queryURL      *url.URL
+	mgmtURL       *url.URL
 	client        *http.Client
+	pipeline      runtime.Pipeline
 	scope         []string
 	clientOptions *azcore.ClientOptions
 	appId         string
+	retry         RetryOptions
+
+	// pipelineOptions holds the pipeline settings of the Options, and cloudInfo the CloudInfo set with
+	// WithCloudInfo.
+	pipelineOptions azcore.ClientOptions
+	cloudInfo       *CloudInfo
+
+	// noAuth is set by WithoutAuth: requests are sent without a token, and the endpoint metadata is not queried.
+	noAuth bool
+
+	// initMu serializes init, and initialized is set once it succeeded.
+	initMu      sync.Mutex
+	initialized atomic.Bool
 }
 
 type Metadata struct {
@@ -52,25 +97,37 @@ type CloudInfo struct {
 }
 
 type QueryMsg struct {
-	DB  string `json:"db"`
-	CSL string `json:"csl"`
+	DB         string                   `json:"db"`
+	CSL        string                   `json:"csl"`
+	Properties *query.RequestProperties `json:"properties,omitempty"`
 }
 
-func (c *Conn) QueryAzureMetadataEndpoint() (*CloudInfo, error) {
+// QueryAzureMetadataEndpoint returns the cloud information published by the endpoint. DefaultCloudInfo is
+// returned when the endpoint does not publish it.
+func (c *Conn) QueryAzureMetadataEndpoint(ctx context.Context) (*CloudInfo, error) {
 	url := c.endpoint.JoinPath(metadataPath)
 
-	resp, err := http.Get(url.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		return nil, errors.ErrWrapf(err, "failed to create metadata request")
+	}
+
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, errors.ErrWrapf(err, "failed to get metadata")
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		ci := DefaultCloudInfo
+		return &ci, nil
+	}
 
 	// Handle internal server error as a special case and return as an error (to be consistent with other SDK's)
-	if resp.StatusCode >= 300 && resp.StatusCode != 404 {
+	if resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("error %s when querying endpoint %s", resp.Status, url.String())
 	}
 
-	defer resp.Body.Close()
-
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -97,23 +154,25 @@ func UnmarshalCloudInfo(b []byte) (*CloudInfo, error) {
 	return &md.AzureAD, nil
 }
 
+// NewClientOptions returns the options of the pipeline of the Conn, with the policies set by its Options,
+// authenticating against the login endpoint of ci.
 func (c *Conn) NewClientOptions(ci *CloudInfo) *azcore.ClientOptions {
-	clientOptions := &azcore.ClientOptions{
-		Transport: c.client,
-		Retry: policy.RetryOptions{
-			MaxRetries: 3,
-			TryTimeout: 10,
-		},
-		Cloud: cloud.Configuration{
-			ActiveDirectoryAuthorityHost: ci.LoginEndpoint,
-		},
+	clientOptions := c.pipelineOptions
+	clientOptions.Transport = c.client
+	// Requests are retried by the Conn, as configured with SetRetryOptions.
+	clientOptions.Retry = policy.RetryOptions{
+		MaxRetries: -1,
+	}
+	clientOptions.Cloud = cloud.Configuration{
+		ActiveDirectoryAuthorityHost: ci.LoginEndpoint,
 	}
 
-	return clientOptions
+	return &clientOptions
 }
 
-// NewConn returns a new Conn object with an injected http.Client
-func NewConn(endpoint string, cred azcore.TokenCredential, client *http.Client) (*Conn, error) {
+// NewConn returns a new Conn object with an injected http.Client, used as the transport of its pipeline.
+// It does not send any request: the endpoint metadata is queried by the first request, or by Ping.
+func NewConn(endpoint string, cred azcore.TokenCredential, client *http.Client, options ...Option) (*Conn, error) {
 	u, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, errors.ErrWrapf(err, "failed to parse endpoint")
@@ -130,13 +189,40 @@ func NewConn(endpoint string, cred azcore.TokenCredential, client *http.Client)
 	c := &Conn{
 		auth:     cred,
 		queryURL: u.JoinPath("/v2/rest/query"),
+		mgmtURL:  u.JoinPath("/v1/rest/mgmt"),
 		client:   client,
 		endpoint: u,
+		retry:    DefaultRetryOptions(),
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.auth == nil && !c.noAuth {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "credential cannot be nil without WithoutAuth")
+	}
+
+	return c, nil
+}
+
+// init resolves the cloud information of the endpoint and builds the pipeline of the Conn, before its first
+// request. It is retried by the following requests when it fails.
+func (c *Conn) init(ctx context.Context) error {
+	if c.initialized.Load() {
+		return nil
+	}
+
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+
+	if c.initialized.Load() {
+		return nil
 	}
 
-	cloudInfo, err := c.QueryAzureMetadataEndpoint()
+	cloudInfo, err := c.CloudInfo(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	c.SetScope(cloudInfo)
@@ -145,9 +231,42 @@ func NewConn(endpoint string, cred azcore.TokenCredential, client *http.Client)
 
 	c.clientOptions = clientOptions
 
+	c.pipeline = c.newPipeline()
+
 	c.appId = cloudInfo.KustoClientAppID
 
-	return c, nil
+	c.initialized.Store(true)
+
+	return nil
+}
+
+// newPipeline returns the pipeline sending the requests of the Conn. The policies of the client options run
+// once per request, before the retry policy, and the bearer token policy authorizes every try unless
+// WithoutAuth is set.
+func (c *Conn) newPipeline() runtime.Pipeline {
+	options := *c.clientOptions
+	perCall := append(append([]policy.Policy{}, options.PerCallPolicies...), retryPolicy{conn: c})
+	options.PerCallPolicies = nil
+
+	var perRetry []policy.Policy
+	if !c.noAuth {
+		perRetry = append(perRetry, runtime.NewBearerTokenPolicy(c.auth, c.scope, nil))
+	}
+
+	return runtime.NewPipeline(moduleName, moduleVersion, runtime.PipelineOptions{
+		PerCall:  perCall,
+		PerRetry: perRetry,
+		AllowedHeaders: []string{
+			ClientRequestIdHeader,
+			ApplicationHeader,
+			UserHeader,
+		},
+	}, &options)
+}
+
+// SetRetryOptions sets how the failed requests of the Conn are retried.
+func (c *Conn) SetRetryOptions(o RetryOptions) {
+	c.retry = o
 }
 
 func (c *Conn) SetScope(ci *CloudInfo) {
@@ -158,110 +277,258 @@ func (c *Conn) SetScope(ci *CloudInfo) {
 	c.scope = []string{fmt.Sprintf("%s/.default", resourceURI)}
 }
 
-func (c *Conn) Query(ctx context.Context, db string, query string, options *query.QueryOptions) error {
-	//c.conn.Query(ctx, "eventmapper", "transaction_logs | take 10", nil)
-	token, err := c.auth.GetToken(ctx, policy.TokenRequestOptions{
-		Scopes: c.scope,
-	})
+// Query runs the query against db and returns all the tables of the response. When the service reports
+// partial failures, the tables received are returned along with a *errors.PartialFailureError.
+func (c *Conn) Query(ctx context.Context, db string, csl string, options *query.QueryOptions) (*table.Dataset, error) {
+	it, err := c.QueryIter(ctx, db, csl, options)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
 
+	ds, err := it.ReadAll()
+	if err != nil {
+		if ds != nil {
+			return ds, err
+		}
+		return nil, errors.ErrWrapf(err, "failed to decode query response")
+	}
+
+	return ds, nil
+}
+
+// QueryIter runs the query against db and returns an iterator decoding the response rows as they are
+// received. The caller must Close the iterator.
+func (c *Conn) QueryIter(ctx context.Context, db string, csl string, options *query.QueryOptions, iterOptions ...frames.IteratorOption) (*frames.RowIterator, error) {
+	body, err := c.execute(ctx, c.queryURL, db, csl, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if options != nil {
+		opts := append([]frames.IteratorOption{}, options.IteratorOptions...)
+		if options.LenientPartialFailures {
+			opts = append([]frames.IteratorOption{frames.WithLenientPartialFailures()}, opts...)
+		}
+		iterOptions = append(opts, iterOptions...)
+	}
+
+	return frames.NewRowIterator(body, iterOptions...), nil
+}
+
+// Mgmt runs the management command against db through the v1 endpoint and returns the tables of the response.
+// When the service reports errors in the tables, the rows received are returned along with a
+// *errors.PartialFailureError.
+func (c *Conn) Mgmt(ctx context.Context, db string, csl string, options *query.QueryOptions) (*table.Dataset, error) {
+	body, err := c.execute(ctx, c.mgmtURL, db, csl, options, c.retry.RetryMgmt)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	ds, err := frames.DecodeV1(body)
+	if err != nil {
+		if ds != nil {
+			return ds, err
+		}
+		return nil, errors.ErrWrapf(err, "failed to decode management response")
+	}
+
+	return ds, nil
+}
+
+// Ping checks that the endpoint is reachable and that the credential is authorized, by running
+// `.show version`. It initializes the Conn if no request was sent yet.
+func (c *Conn) Ping(ctx context.Context) error {
+	body, err := c.execute(ctx, c.mgmtURL, pingDB, ".show version", nil, true)
 	if err != nil {
 		return err
 	}
+	return body.Close()
+}
 
-	headers := c.getHeaders()
-	headers.Add("Authorization", fmt.Sprintf("Bearer %s", token.Token))
+// execute posts csl to the endpoint u and returns the decompressed body of a successful response,
+// retrying failed requests when retry is true. The caller must close the body.
+func (c *Conn) execute(ctx context.Context, u *url.URL, db string, csl string, options *query.QueryOptions, retry bool) (io.ReadCloser, error) {
+	if err := c.init(ctx); err != nil {
+		return nil, err
+	}
+
+	var properties *query.RequestProperties
+	if options != nil {
+		properties = options.RequestProperties
+	}
+	properties = withDeadline(ctx, properties)
 
 	buff := bufferPool.Get().(*bytes.Buffer)
 	buff.Reset()
 	defer bufferPool.Put(buff)
 
-	err = json.NewEncoder(buff).Encode(
+	err := json.NewEncoder(buff).Encode(
 		QueryMsg{
-			DB:  db,
-			CSL: query,
+			DB:         db,
+			CSL:        options.Statement(csl),
+			Properties: properties,
 		},
 	)
 	if err != nil {
-		return errors.ErrWrapf(err, "failed to encode query")
+		return nil, errors.ErrWrapf(err, "failed to encode query")
 	}
 
-	req := &http.Request{
-		Method: http.MethodPost,
-		URL:    c.queryURL,
-		Header: headers,
-		Body:   io.NopCloser(buff),
+	req, err := runtime.NewRequest(ctx, http.MethodPost, u.String())
+	if err != nil {
+		return nil, errors.ErrWrapf(err, "failed to create request")
+	}
+
+	headers := c.getHeaders(properties)
+	for name, values := range headers {
+		req.Raw().Header[name] = values
 	}
 
-	resp, err := c.client.Do(req.WithContext(ctx))
+	// The body is copied out of the pooled buffer: the request outlives execute, as the response is streamed
+	// to the caller, and the retry policy may send it again.
+	msg := append([]byte(nil), buff.Bytes()...)
+	if err := req.SetBody(streaming.NopCloser(bytes.NewReader(msg)), headers.Get("Content-Type")); err != nil {
+		return nil, errors.ErrWrapf(err, "failed to set request body")
+	}
+
+	// Query responses are streamed to the caller instead of being read in memory.
+	runtime.SkipBodyDownload(req)
+	if !retry {
+		req.SetOperationValue(noRetry{})
+	}
+
+	// Queries still running on the service when ctx is done are canceled.
+	if u != c.queryURL || ctx.Err() != nil {
+		return c.do(req)
+	}
+
+	stop := c.watchCancel(ctx, db, headers.Get(ClientRequestIdHeader))
+	body, err := c.do(req)
 	if err != nil {
-		return err
+		if ctx.Err() == nil {
+			stop()
+		}
+		return nil, err
 	}
+	return &cancelBody{ReadCloser: body, stop: stop}, nil
+}
 
-	defer resp.Body.Close()
+// do sends req through the pipeline and returns the decompressed body of a successful response, or the
+// KustoError of a failed one.
+func (c *Conn) do(req *policy.Request) (io.ReadCloser, error) {
+	resp, err := c.pipeline.Do(req)
+	if err != nil {
+		return nil, err
+	}
 
+	body, err := decodeBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer body.Close()
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "error %s when querying endpoint %s", resp.Status, req.Raw().URL.String())
+		}
+		kerr := errors.NewKustoError(resp.StatusCode, resp.Header, b)
+		if kerr.ClientRequestID == "" {
+			kerr.ClientRequestID = req.Raw().Header.Get(ClientRequestIdHeader)
+		}
+		return nil, kerr
+	}
+
+	return body, nil
+}
+
+// decodeBody returns a reader over the response body, decompressed according to its Content-Encoding.
+// Closing the returned reader also closes the response body.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
 	switch enc := strings.ToLower(resp.Header.Get("Content-Encoding")); enc {
 	case "":
-		fmt.Printf("resp.Body: %v\n", resp.Body)
+		return resp.Body, nil
 	case "gzip":
-		var err error
 		wrapper, err := gzip.NewReader(resp.Body)
 		if err != nil {
-			return fmt.Errorf("gzip reader error: %w", err)
+			return nil, errors.ErrWrapf(err, "gzip reader error")
 		}
-		defer wrapper.Close()
-
-		all, e := io.ReadAll(wrapper)
-		if e != nil {
-			return fmt.Errorf("gzip reader error: %w", e)
-		}
-		fmt.Printf("gzip all: %s\n", string(all))
-
+		return &bodyReader{Reader: wrapper, closers: []io.Closer{wrapper, resp.Body}}, nil
 	case "deflate":
 		wrapper := flate.NewReader(resp.Body)
-		all, e := io.ReadAll(wrapper)
-		if err != nil {
-			return errors.ErrWrapf(e, "deflate reader error")
-		}
-		fmt.Printf("deflate all: %v\n", string(all))
-		defer wrapper.Close()
+		return &bodyReader{Reader: wrapper, closers: []io.Closer{wrapper, resp.Body}}, nil
 	default:
-		return fmt.Errorf("Content-Encoding was unrecognized: %s", enc)
+		return nil, fmt.Errorf("Content-Encoding was unrecognized: %s", enc)
 	}
+}
 
-	return nil
+// bodyReader reads a decompressed response body and closes both the decompressor and the body.
+type bodyReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *bodyReader) Close() error {
+	var err error
+	for _, c := range b.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
+// Scope returns the scope of the tokens authorizing requests. It is nil until a request of the Conn resolved
+// its cloud information, which is safe to check concurrently with requests.
 func (c *Conn) Scope() []string {
-	return c.scope
+	if !c.initialized.Load() {
+		return nil
+	}
+	return append([]string(nil), c.scope...)
 }
 
-func (c *Conn) getHeaders( /*properties requestProperties*/ ) http.Header {
+// getHeaders returns the headers of a request, tracing it with the client request ID, application and
+// user of properties, or with defaults when they are not set.
+func (c *Conn) getHeaders(properties *query.RequestProperties) http.Header {
 	header := http.Header{}
 	header.Add("Accept", "application/json")
 	header.Add("Accept-Encoding", "gzip, deflate")
 	header.Add("Content-Type", "application/json; charset=utf-8")
 	header.Add("Connection", "Keep-Alive")
 	header.Add("x-ms-version", "2019-02-13")
-	/*
-		if properties.ClientRequestID != "" {
-			header.Add(ClientRequestIdHeader, properties.ClientRequestID)
-		} else {
-			header.Add(ClientRequestIdHeader, "KGC.execute;"+uuid.New().String())
-		}
 
-		if properties.Application != "" {
-			header.Add(ApplicationHeader, properties.Application)
-		} else {
-			header.Add(ApplicationHeader, c.clientDetails.ApplicationForTracing())
-		}
+	if properties == nil {
+		properties = &query.RequestProperties{}
+	}
 
-		if properties.User != "" {
-			header.Add(UserHeader, properties.User)
-		} else {
-			header.Add(UserHeader, c.clientDetails.UserNameForTracing())
-		}
+	if properties.ClientRequestID != "" {
+		header.Add(ClientRequestIdHeader, properties.ClientRequestID)
+	} else {
+		header.Add(ClientRequestIdHeader, "KGC.execute;"+uuid.New().String())
+	}
+
+	if properties.Application != "" {
+		header.Add(ApplicationHeader, properties.Application)
+	} else {
+		header.Add(ApplicationHeader, defaultApplication)
+	}
+
+	if properties.User != "" {
+		header.Add(UserHeader, properties.User)
+	} else {
+		header.Add(UserHeader, defaultUser)
+	}
 
-		header.Add(ClientVersionHeader, c.clientDetails.ClientVersionForTracing())
-	*/
 	return header
 }
+
+// currentUser returns the name of the user running the process, for tracing.
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil || u.Username == "" {
+		return "[none]"
+	}
+	return u.Username
+}
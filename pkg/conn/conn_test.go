@@ -0,0 +1,307 @@
+package conn
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+)
+
+const emptyV2Response = `[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0"},{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}]`
+
+// newTestServer returns a server serving the metadata endpoint and passing query requests to handler.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(metadataPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"AzureAD":{"LoginEndpoint":"https://login.microsoftonline.com","KustoServiceResourceId":"https://kusto.kusto.windows.net"}}`))
+	})
+	mux.HandleFunc("/v2/rest/query", handler)
+
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestQuerySendsProperties(t *testing.T) {
+	var (
+		msg    map[string]interface{}
+		header http.Header
+	)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Write([]byte(emptyV2Response))
+	})
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	opts, err := query.NewQueryOptions(
+		query.ClientRequestID("request-id"),
+		query.Application("app"),
+		query.User("user"),
+		query.NoTruncation(),
+	)
+	if err != nil {
+		t.Fatalf("NewQueryOptions() returned error: %v", err)
+	}
+
+	if _, err := c.Query(context.Background(), "db", "T | take 1", opts); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if msg["db"] != "db" || msg["csl"] != "T | take 1" {
+		t.Errorf("unexpected db and csl: %v", msg)
+	}
+	props, _ := msg["properties"].(map[string]interface{})
+	options, _ := props["Options"].(map[string]interface{})
+	if options[query.NoTruncationValue] != true {
+		t.Errorf("properties = %v, want Options.%s = true", msg["properties"], query.NoTruncationValue)
+	}
+
+	for name, want := range map[string]string{
+		ClientRequestIdHeader: "request-id",
+		ApplicationHeader:     "app",
+		UserHeader:            "user",
+		"Authorization":       "Bearer token",
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestQueryDefaultHeaders(t *testing.T) {
+	var header http.Header
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(emptyV2Response))
+	})
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	if _, err := c.Query(context.Background(), "db", "T", nil); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	for _, name := range []string{ClientRequestIdHeader, ApplicationHeader, UserHeader} {
+		if header.Get(name) == "" {
+			t.Errorf("header %s is not set", name)
+		}
+	}
+}
+
+func TestMgmt(t *testing.T) {
+	var msg QueryMsg
+	mux := http.NewServeMux()
+	mux.HandleFunc(metadataPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"AzureAD":{"KustoServiceResourceId":"https://kusto.kusto.windows.net"}}`))
+	})
+	mux.HandleFunc("/v1/rest/mgmt", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"TableName","DataType":"String","ColumnType":"string"}],"Rows":[["t1"],["t2"]]}]}`))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	ds, err := c.Mgmt(context.Background(), "db", ".show tables", nil)
+	if err != nil {
+		t.Fatalf("Mgmt() returned error: %v", err)
+	}
+
+	if msg.DB != "db" || msg.CSL != ".show tables" {
+		t.Errorf("unexpected request: %+v", msg)
+	}
+	if len(ds.Tables) != 1 || len(ds.Tables[0].Rows) != 2 || ds.Tables[0].Rows[1].Values[0].String() != "t2" {
+		t.Errorf("unexpected dataset: %+v", ds.Tables)
+	}
+}
+
+func TestQueryKustoError(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"BadRequest_SyntaxError","message":"Request is invalid","@permanent":true}}`))
+	})
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	opts, err := query.NewQueryOptions(query.ClientRequestID("request-id"))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() returned error: %v", err)
+	}
+
+	_, err = c.Query(context.Background(), "db", "T |", opts)
+	var kerr *errors.KustoError
+	if !stderrors.As(err, &kerr) {
+		t.Fatalf("Query() returned %v, want a KustoError", err)
+	}
+	if kerr.StatusCode != http.StatusBadRequest || kerr.Code != "BadRequest_SyntaxError" || kerr.ClientRequestID != "request-id" {
+		t.Errorf("unexpected error: %+v", kerr)
+	}
+}
+
+// countingPolicy counts the requests going through it.
+type countingPolicy struct {
+	count *int32
+}
+
+func (p countingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	atomic.AddInt32(p.count, 1)
+	return req.Next()
+}
+
+func TestQueryPipelinePolicies(t *testing.T) {
+	var (
+		trys      int32
+		userAgent string
+	)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		if atomic.AddInt32(&trys, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(emptyV2Response))
+	})
+
+	var perCall, perRetry int32
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client(),
+		WithPerCallPolicies(countingPolicy{count: &perCall}),
+		WithPerRetryPolicies(countingPolicy{count: &perRetry}),
+		WithTelemetry(policy.TelemetryOptions{ApplicationID: "myapp"}),
+	)
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	c.SetRetryOptions(RetryOptions{MaxRetries: 1, RetryDelay: time.Millisecond})
+
+	if _, err := c.Query(context.Background(), "db", "T", nil); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if perCall != 1 || perRetry != 2 {
+		t.Errorf("per call policy ran %d times and per retry policy %d times, want 1 and 2", perCall, perRetry)
+	}
+	if !strings.HasPrefix(userAgent, "myapp azsdk-go-"+moduleName) {
+		t.Errorf("User-Agent = %q", userAgent)
+	}
+}
+
+func TestNewConnNoRequest(t *testing.T) {
+	var requests int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if _, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client()); err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("NewConn() sent %d requests, want 0", requests)
+	}
+}
+
+func TestInitRetriedAfterError(t *testing.T) {
+	var (
+		down    atomic.Bool
+		pingMsg QueryMsg
+	)
+	down.Store(true)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(metadataPath, func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"AzureAD":{"KustoServiceResourceId":"https://kusto.kusto.windows.net"}}`))
+	})
+	mux.HandleFunc("/v1/rest/mgmt", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&pingMsg); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"Tables":[]}`))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	if err := c.Ping(context.Background()); err == nil {
+		t.Fatalf("Ping() returned no error while the endpoint is down")
+	}
+	if got := c.Scope(); got != nil {
+		t.Errorf("Scope() before a successful request = %v, want nil", got)
+	}
+
+	down.Store(false)
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() returned error: %v", err)
+	}
+	if pingMsg.CSL != ".show version" {
+		t.Errorf("Ping() sent %q", pingMsg.CSL)
+	}
+	if got := c.Scope(); len(got) != 1 || got[0] != "https://kusto.kusto.windows.net/.default" {
+		t.Errorf("Scope() = %v", got)
+	}
+}
+
+func TestScopeConcurrentWithInit(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if got := c.Scope(); got != nil && len(got) != 1 {
+				t.Errorf("Scope() = %v", got)
+			}
+		}
+	}()
+	if err := c.init(context.Background()); err != nil {
+		t.Errorf("init() returned error: %v", err)
+	}
+	<-done
+
+	if got := c.Scope(); len(got) != 1 {
+		t.Errorf("Scope() after init() = %v", got)
+	}
+}
@@ -0,0 +1,36 @@
+package conn
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// staticTokenLifetime is the lifetime reported for a StaticTokenCredential token with no ExpiresOn.
+const staticTokenLifetime = time.Hour
+
+// StaticTokenCredential authenticates with a token obtained by other means, such as a test token or a token
+// of a connection string.
+type StaticTokenCredential struct {
+	// Token is the bearer token sent with the requests.
+	Token string
+	// ExpiresOn is the expiration of Token. When zero, Token is reported to expire an hour after each call
+	// to GetToken, so that it is never refreshed.
+	ExpiresOn time.Time
+}
+
+// NewStaticTokenCredential returns a StaticTokenCredential for token.
+func NewStaticTokenCredential(token string) StaticTokenCredential {
+	return StaticTokenCredential{Token: token}
+}
+
+// GetToken implements azcore.TokenCredential.
+func (c StaticTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	expiresOn := c.ExpiresOn
+	if expiresOn.IsZero() {
+		expiresOn = time.Now().Add(staticTokenLifetime)
+	}
+	return azcore.AccessToken{Token: c.Token, ExpiresOn: expiresOn}, nil
+}
@@ -0,0 +1,25 @@
+package conn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+func TestStaticTokenCredential(t *testing.T) {
+	token, err := NewStaticTokenCredential("token").GetToken(context.Background(), policy.TokenRequestOptions{})
+	if err != nil {
+		t.Fatalf("GetToken() returned error: %v", err)
+	}
+	if token.Token != "token" || !token.ExpiresOn.After(time.Now()) {
+		t.Errorf("GetToken() = %+v, want an unexpired token", token)
+	}
+
+	expiresOn := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	token, _ = StaticTokenCredential{Token: "token", ExpiresOn: expiresOn}.GetToken(context.Background(), policy.TokenRequestOptions{})
+	if !token.ExpiresOn.Equal(expiresOn) {
+		t.Errorf("GetToken() expires on %v, want %v", token.ExpiresOn, expiresOn)
+	}
+}
@@ -0,0 +1,51 @@
+package conn
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// Option configures a Conn.
+type Option func(c *Conn)
+
+// WithPerCallPolicies adds policies that run once per request, before it is retried.
+func WithPerCallPolicies(policies ...policy.Policy) Option {
+	return func(c *Conn) {
+		c.pipelineOptions.PerCallPolicies = append(c.pipelineOptions.PerCallPolicies, policies...)
+	}
+}
+
+// WithPerRetryPolicies adds policies that run on every try of a request, after it is authorized.
+func WithPerRetryPolicies(policies ...policy.Policy) Option {
+	return func(c *Conn) {
+		c.pipelineOptions.PerRetryPolicies = append(c.pipelineOptions.PerRetryPolicies, policies...)
+	}
+}
+
+// WithLogging configures the logging policy.
+func WithLogging(o policy.LogOptions) Option {
+	return func(c *Conn) {
+		c.pipelineOptions.Logging = o
+	}
+}
+
+// WithTelemetry configures the telemetry policy setting the User-Agent header.
+func WithTelemetry(o policy.TelemetryOptions) Option {
+	return func(c *Conn) {
+		c.pipelineOptions.Telemetry = o
+	}
+}
+
+// WithCloudInfo sets the cloud information of the endpoint, so that it is not discovered from its metadata.
+func WithCloudInfo(ci CloudInfo) Option {
+	return func(c *Conn) {
+		c.cloudInfo = &ci
+	}
+}
+
+// WithoutAuth sends requests without authorizing them, and skips the discovery of the cloud information. It
+// is meant for local emulators, such as Kustainer, that accept unauthenticated requests over plain HTTP.
+func WithoutAuth() Option {
+	return func(c *Conn) {
+		c.noAuth = true
+	}
+}
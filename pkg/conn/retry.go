@@ -0,0 +1,151 @@
+package conn
+
+import (
+	"bytes"
+	stderrors "errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
+// RetryOptions configures how failed requests are retried. Requests are retried when the service is
+// throttling (429) or unavailable (503), and when the connection was reset, unless the service reports
+// the failure as permanent.
+type RetryOptions struct {
+	// MaxRetries is the maximum number of times a request is retried. 0 disables retries.
+	MaxRetries int
+	// RetryDelay is the delay before the first retry. It is doubled for every following retry.
+	RetryDelay time.Duration
+	// MaxRetryDelay caps the delay between two tries, including the delay asked with Retry-After.
+	MaxRetryDelay time.Duration
+	// RetryMgmt enables the retries of management commands. They are not retried by default, as a
+	// command that failed may still have been applied.
+	RetryMgmt bool
+}
+
+// DefaultRetryOptions returns the RetryOptions used by a new Conn.
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxRetries:    3,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 30 * time.Second,
+	}
+}
+
+// retryable reports whether a request that failed with err can be retried.
+func retryable(err error) bool {
+	var kerr *errors.KustoError
+	if stderrors.As(err, &kerr) {
+		if kerr.Permanent {
+			return false
+		}
+		return kerr.IsThrottled() || kerr.StatusCode == http.StatusServiceUnavailable
+	}
+
+	return stderrors.Is(err, syscall.ECONNRESET) ||
+		stderrors.Is(err, io.EOF) ||
+		stderrors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// delay returns how long to wait before the retry following the given try, counting from 0. The delay
+// asked by the service through Retry-After is honored, otherwise it grows exponentially with a random jitter.
+func (o RetryOptions) delay(try int, err error) time.Duration {
+	var kerr *errors.KustoError
+	if stderrors.As(err, &kerr) && kerr.RetryAfter > 0 {
+		return o.cap(kerr.RetryAfter)
+	}
+
+	d := o.RetryDelay
+	for i := 0; i < try && (o.MaxRetryDelay <= 0 || d < o.MaxRetryDelay); i++ {
+		d *= 2
+	}
+	d = o.cap(d)
+
+	// Wait between half and all of the delay, so that clients throttled together do not retry together.
+	if d > 1 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint:gosec // jitter does not need a secure source
+	}
+	return d
+}
+
+// cap limits d to MaxRetryDelay.
+func (o RetryOptions) cap(d time.Duration) time.Duration {
+	if o.MaxRetryDelay > 0 && d > o.MaxRetryDelay {
+		return o.MaxRetryDelay
+	}
+	return d
+}
+
+// noRetry is set as the operation value of requests that must not be retried.
+type noRetry struct{}
+
+// retryPolicy is the pipeline policy retrying the failed requests of a Conn with its RetryOptions.
+type retryPolicy struct {
+	conn *Conn
+}
+
+// Do implements policy.Policy. It tries req until it succeeds, fails with an error that cannot be retried,
+// the retries are exhausted or the context of req is done.
+func (p retryPolicy) Do(req *policy.Request) (*http.Response, error) {
+	o := p.conn.retry
+	ctx := req.Raw().Context()
+
+	var skip noRetry
+	if req.OperationValue(&skip) {
+		o.MaxRetries = 0
+	}
+
+	for try := 0; ; try++ {
+		if err := req.RewindBody(); err != nil {
+			return nil, err
+		}
+
+		// failure is the error of the try, either a transport error or the KustoError of a failed response.
+		// A failed response is returned as is, for the caller to report its error.
+		resp, err := req.Clone(ctx).Next()
+		failure := err
+		if err == nil && resp.StatusCode != http.StatusOK {
+			kerr, rerr := responseError(resp)
+			if rerr != nil {
+				return nil, rerr
+			}
+			failure = kerr
+		}
+		if failure == nil || try >= o.MaxRetries || !retryable(failure) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		timer := time.NewTimer(o.delay(try, failure))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// responseError reads the body of a failed response and returns its KustoError. The body is replaced with
+// its decompressed content, so that it can be read again.
+func responseError(resp *http.Response) (*errors.KustoError, error) {
+	body, err := decodeBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.ErrWrapf(err, "error %s when querying endpoint %s", resp.Status, resp.Request.URL.String())
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	return errors.NewKustoError(resp.StatusCode, resp.Header, b), nil
+}
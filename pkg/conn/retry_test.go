@@ -0,0 +1,181 @@
+package conn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
+// fastRetries retries without waiting long, so that tests stay fast.
+var fastRetries = RetryOptions{
+	MaxRetries:    3,
+	RetryDelay:    time.Millisecond,
+	MaxRetryDelay: 5 * time.Millisecond,
+}
+
+func TestQueryRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		failures int32
+		wantErr  bool
+		wantTrys int32
+	}{
+		{name: "throttled", status: http.StatusTooManyRequests, failures: 2, wantTrys: 3},
+		{name: "unavailable", status: http.StatusServiceUnavailable, failures: 1, wantTrys: 2},
+		{name: "retries exhausted", status: http.StatusServiceUnavailable, failures: 10, wantErr: true, wantTrys: 4},
+		{
+			name:     "permanent",
+			status:   http.StatusServiceUnavailable,
+			body:     `{"error":{"code":"ServiceUnavailable","message":"down","@permanent":true}}`,
+			failures: 10,
+			wantErr:  true,
+			wantTrys: 1,
+		},
+		{name: "bad request", status: http.StatusBadRequest, failures: 10, wantErr: true, wantTrys: 1},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var trys int32
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&trys, 1) <= test.failures {
+					w.WriteHeader(test.status)
+					w.Write([]byte(test.body))
+					return
+				}
+				w.Write([]byte(emptyV2Response))
+			})
+
+			c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+			if err != nil {
+				t.Fatalf("NewConn() returned error: %v", err)
+			}
+			c.SetRetryOptions(fastRetries)
+
+			_, err = c.Query(context.Background(), "db", "T", nil)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Query() returned error %v, want error %v", err, test.wantErr)
+			}
+			if trys != test.wantTrys {
+				t.Errorf("server got %d requests, want %d", trys, test.wantTrys)
+			}
+		})
+	}
+}
+
+func TestQueryRetriesConnectionReset(t *testing.T) {
+	var trys int32
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&trys, 1) == 1 {
+			// Drop the connection without answering.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("failed to hijack connection: %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.Write([]byte(emptyV2Response))
+	})
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	c.SetRetryOptions(fastRetries)
+
+	if _, err := c.Query(context.Background(), "db", "T", nil); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if trys != 2 {
+		t.Errorf("server got %d requests, want 2", trys)
+	}
+}
+
+func TestMgmtRetries(t *testing.T) {
+	for _, retryMgmt := range []bool{false, true} {
+		var trys int32
+		mux := http.NewServeMux()
+		mux.HandleFunc(metadataPath, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"AzureAD":{"KustoServiceResourceId":"https://kusto.kusto.windows.net"}}`))
+		})
+		mux.HandleFunc("/v1/rest/mgmt", func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&trys, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"Tables":[]}`))
+		})
+		srv := httptest.NewTLSServer(mux)
+		defer srv.Close()
+
+		c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+		if err != nil {
+			t.Fatalf("NewConn() returned error: %v", err)
+		}
+		opts := fastRetries
+		opts.RetryMgmt = retryMgmt
+		c.SetRetryOptions(opts)
+
+		_, err = c.Mgmt(context.Background(), "db", ".drop table T", nil)
+		if (err == nil) != retryMgmt {
+			t.Errorf("RetryMgmt %v: Mgmt() returned error %v", retryMgmt, err)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	o := RetryOptions{MaxRetries: 5, RetryDelay: time.Second, MaxRetryDelay: 10 * time.Second}
+	unavailable := &errors.KustoError{StatusCode: http.StatusServiceUnavailable}
+
+	for try, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second} {
+		d := o.delay(try, unavailable)
+		if d < want/2 || d > want {
+			t.Errorf("delay(%d) = %v, want between %v and %v", try, d, want/2, want)
+		}
+	}
+
+	throttled := &errors.KustoError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}
+	if d := o.delay(0, throttled); d != 3*time.Second {
+		t.Errorf("delay() with Retry-After = %v, want 3s", d)
+	}
+	throttled.RetryAfter = time.Minute
+	if d := o.delay(0, throttled); d != 10*time.Second {
+		t.Errorf("delay() with Retry-After = %v, want MaxRetryDelay", d)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	var trys int32
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&trys, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	c, err := NewConn(srv.URL, NewStaticTokenCredential("token"), srv.Client())
+	if err != nil {
+		t.Fatalf("NewConn() returned error: %v", err)
+	}
+	c.SetRetryOptions(RetryOptions{MaxRetries: 3, RetryDelay: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = c.Query(ctx, "db", "T", nil)
+	if !errors.IsThrottled(err) {
+		t.Errorf("Query() returned %v, want a throttling error", err)
+	}
+	if trys != 1 {
+		t.Errorf("server got %d requests, want 1", trys)
+	}
+}
@@ -0,0 +1,205 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers the service returns to identify a request.
+const (
+	clientRequestIDHeader = "x-ms-client-request-id"
+	activityIDHeader      = "x-ms-activity-id"
+	retryAfterHeader      = "Retry-After"
+)
+
+// KustoError is an error returned by the Kusto service, parsed from the OneApi error payload
+// {"error":{"code", "message", "@type", "@message", "@permanent", ...}} of a failed request.
+type KustoError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the error code, such as BadRequest_SyntaxError.
+	Code string
+	// Message is the short description of the error.
+	Message string
+	// Type is the type of the exception raised by the service.
+	Type string
+	// Description is the detailed description of the error.
+	Description string
+	// Permanent indicates that retrying the request will fail again.
+	Permanent bool
+	// ClientRequestID is the client request ID of the failed request.
+	ClientRequestID string
+	// ActivityID is the ID the service assigned to the failed request.
+	ActivityID string
+	// Context holds the additional information of the @context field.
+	Context map[string]interface{}
+	// Body holds the response body when it is not a OneApi error payload.
+	Body string
+	// RetryAfter is the delay the service asked to wait before retrying, from the Retry-After header.
+	RetryAfter time.Duration
+}
+
+// oneAPIError is the OneApi error payload.
+type oneAPIError struct {
+	Error struct {
+		Code        string                 `json:"code"`
+		Message     string                 `json:"message"`
+		Type        string                 `json:"@type"`
+		Description string                 `json:"@message"`
+		Permanent   *bool                  `json:"@permanent"`
+		Context     map[string]interface{} `json:"@context"`
+	} `json:"error"`
+}
+
+// NewKustoError returns the KustoError of a response with the given status, headers and body.
+func NewKustoError(statusCode int, header http.Header, body []byte) *KustoError {
+	e := &KustoError{
+		StatusCode:      statusCode,
+		ClientRequestID: header.Get(clientRequestIDHeader),
+		ActivityID:      header.Get(activityIDHeader),
+		RetryAfter:      parseRetryAfter(header.Get(retryAfterHeader)),
+	}
+
+	payload := oneAPIError{}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Error.Code == "" {
+		e.Body = string(body)
+		e.Permanent = permanentStatus(statusCode)
+		return e
+	}
+
+	e.setPayload(payload)
+	return e
+}
+
+// ParseOneAPIError parses a OneApi error payload embedded in a successful response, such as the
+// OneApiErrors of a DataTable row or of the DataSetCompletion frame.
+func ParseOneAPIError(b []byte) (*KustoError, error) {
+	payload := oneAPIError{}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return nil, ErrWrapf(err, "failed to decode OneApi error")
+	}
+
+	e := &KustoError{StatusCode: http.StatusOK}
+	e.setPayload(payload)
+	return e, nil
+}
+
+// setPayload sets the fields of e from the OneApi error payload.
+func (e *KustoError) setPayload(payload oneAPIError) {
+	e.Code = payload.Error.Code
+	e.Message = payload.Error.Message
+	e.Type = payload.Error.Type
+	e.Description = payload.Error.Description
+	e.Context = payload.Error.Context
+	if payload.Error.Permanent != nil {
+		e.Permanent = *payload.Error.Permanent
+	} else {
+		e.Permanent = permanentStatus(e.StatusCode)
+	}
+
+	if id, ok := e.Context["clientRequestId"].(string); ok && e.ClientRequestID == "" {
+		e.ClientRequestID = id
+	}
+	if id, ok := e.Context["activityId"].(string); ok && e.ActivityID == "" {
+		e.ActivityID = id
+	}
+}
+
+// Error implements error.
+func (e *KustoError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("kusto error (status %d", e.StatusCode))
+	if e.Code != "" {
+		sb.WriteString(", code " + e.Code)
+	}
+	if e.ClientRequestID != "" {
+		sb.WriteString(", client request id " + e.ClientRequestID)
+	}
+	if e.ActivityID != "" {
+		sb.WriteString(", activity id " + e.ActivityID)
+	}
+	sb.WriteString(")")
+
+	switch {
+	case e.Description != "":
+		sb.WriteString(": " + e.Description)
+	case e.Message != "":
+		sb.WriteString(": " + e.Message)
+	case e.Body != "":
+		sb.WriteString(": " + e.Body)
+	}
+	return sb.String()
+}
+
+// IsThrottled reports whether the request was rejected because of throttling.
+func (e *KustoError) IsThrottled() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.Code == "TooManyRequests" ||
+		strings.Contains(e.Type, "Throttled")
+}
+
+// IsPermanent reports whether retrying the request will fail again.
+func (e *KustoError) IsPermanent() bool {
+	return e.Permanent
+}
+
+// IsAuth reports whether the request failed because of authentication or authorization.
+func (e *KustoError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized ||
+		e.StatusCode == http.StatusForbidden ||
+		e.Code == "Unauthorized" ||
+		e.Code == "Forbidden"
+}
+
+// IsThrottled reports whether err is or wraps a KustoError caused by throttling.
+func IsThrottled(err error) bool {
+	var e *KustoError
+	return errors.As(err, &e) && e.IsThrottled()
+}
+
+// IsPermanent reports whether err is or wraps a permanent KustoError.
+func IsPermanent(err error) bool {
+	var e *KustoError
+	return errors.As(err, &e) && e.IsPermanent()
+}
+
+// IsAuth reports whether err is or wraps a KustoError caused by authentication or authorization.
+func IsAuth(err error) bool {
+	var e *KustoError
+	return errors.As(err, &e) && e.IsAuth()
+}
+
+// parseRetryAfter parses a Retry-After header holding either a number of seconds or an HTTP date.
+// It returns 0 when the header is missing or invalid.
+func parseRetryAfter(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(s); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// permanentStatus reports whether a status code indicates a permanent failure, for errors that do not
+// carry @permanent. Client errors are permanent, except for timeouts and throttling.
+func permanentStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return statusCode >= 400 && statusCode < 500
+}
@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewKustoError(t *testing.T) {
+	body := `{"error":{"code":"BadRequest_SyntaxError","message":"Request is invalid and cannot be executed.",` +
+		`"@type":"Kusto.Data.Exceptions.SyntaxException","@message":"Syntax error: unexpected token",` +
+		`"@context":{"clientRequestId":"KGC.execute;1","activityId":"activity"},"@permanent":true}}`
+
+	e := NewKustoError(http.StatusBadRequest, http.Header{}, []byte(body))
+
+	if e.Code != "BadRequest_SyntaxError" || e.Type != "Kusto.Data.Exceptions.SyntaxException" {
+		t.Errorf("unexpected code and type: %+v", e)
+	}
+	if e.ClientRequestID != "KGC.execute;1" || e.ActivityID != "activity" {
+		t.Errorf("unexpected ids: %+v", e)
+	}
+	if !e.IsPermanent() || e.IsThrottled() || e.IsAuth() {
+		t.Errorf("unexpected classification: %+v", e)
+	}
+
+	wrapped := fmt.Errorf("query failed: %w", e)
+	var got *KustoError
+	if !errors.As(wrapped, &got) || got != e {
+		t.Errorf("errors.As() did not find the KustoError")
+	}
+	if !IsPermanent(wrapped) || IsThrottled(wrapped) || IsAuth(wrapped) {
+		t.Errorf("unexpected classification of wrapped error")
+	}
+}
+
+func TestNewKustoErrorClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		throttled bool
+		permanent bool
+		auth      bool
+	}{
+		{name: "throttled status", status: http.StatusTooManyRequests, body: `{"error":{"code":"TooManyRequests"}}`, throttled: true},
+		{name: "throttled type", status: http.StatusBadRequest, body: `{"error":{"code":"General_BadRequest","@type":"Kusto.DataNode.Exceptions.ControlCommandThrottledException","@permanent":false}}`, throttled: true},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: ``, auth: true, permanent: true},
+		{name: "forbidden", status: http.StatusForbidden, body: `{"error":{"code":"Forbidden"}}`, auth: true, permanent: true},
+		{name: "server error", status: http.StatusServiceUnavailable, body: `not json`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(activityIDHeader, "activity")
+			e := NewKustoError(test.status, header, []byte(test.body))
+			if e.IsThrottled() != test.throttled || e.IsPermanent() != test.permanent || e.IsAuth() != test.auth {
+				t.Errorf("got throttled=%v permanent=%v auth=%v", e.IsThrottled(), e.IsPermanent(), e.IsAuth())
+			}
+			if e.ActivityID != "activity" {
+				t.Errorf("ActivityID = %q, want activity", e.ActivityID)
+			}
+		})
+	}
+}
+
+func TestNewKustoErrorRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{name: "missing", retryAfter: "", min: 0, max: 0},
+		{name: "seconds", retryAfter: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "date", retryAfter: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 50 * time.Second, max: time.Minute},
+		{name: "past date", retryAfter: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), min: 0, max: 0},
+		{name: "invalid", retryAfter: "soon", min: 0, max: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(retryAfterHeader, test.retryAfter)
+			e := NewKustoError(http.StatusTooManyRequests, header, nil)
+			if e.RetryAfter < test.min || e.RetryAfter > test.max {
+				t.Errorf("RetryAfter = %v, want between %v and %v", e.RetryAfter, test.min, test.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package errors
+
+import (
+	"strings"
+)
+
+// PartialFailureError is returned when the service reports errors inside a successful response, for
+// example when a query fails after some of its rows were sent. The rows received are returned along
+// with the error.
+type PartialFailureError struct {
+	// Errors holds the errors reported by the service.
+	Errors []*KustoError
+}
+
+// Error implements error.
+func (e *PartialFailureError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return "partial query failure: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors reported by the service, so that errors.As finds them.
+func (e *PartialFailureError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
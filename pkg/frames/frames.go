@@ -0,0 +1,137 @@
+// Package frames decodes the frames of a Kusto v2 query response into tables.
+package frames
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+// Frame types sent in a v2 query response.
+const (
+	TypeDataSetHeader     = "DataSetHeader"
+	TypeDataTable         = "DataTable"
+	TypeDataSetCompletion = "DataSetCompletion"
+	TypeTableHeader       = "TableHeader"
+	TypeTableFragment     = "TableFragment"
+	TypeTableProgress     = "TableProgress"
+	TypeTableCompletion   = "TableCompletion"
+)
+
+// Fragment types of a TableFragment frame.
+const (
+	// FragmentDataAppend fragments hold rows to be added to the rows previously received for the table.
+	FragmentDataAppend = "DataAppend"
+	// FragmentDataReplace fragments hold rows replacing all the rows previously received for the table.
+	FragmentDataReplace = "DataReplace"
+)
+
+// DataSetHeader is the first frame of a v2 response.
+type DataSetHeader struct {
+	FrameType     string
+	IsProgressive bool
+	Version       string
+}
+
+// DataTable is a frame holding a complete table.
+type DataTable struct {
+	FrameType string
+	TableID   int `json:"TableId"`
+	TableKind string
+	TableName string
+	Columns   table.Columns
+	Rows      [][]interface{}
+}
+
+// TableHeader is the frame starting a table in a progressive response.
+type TableHeader struct {
+	FrameType string
+	TableID   int `json:"TableId"`
+	TableKind string
+	TableName string
+	Columns   table.Columns
+}
+
+// TableFragment is a frame holding some of the rows of a table started by a TableHeader.
+type TableFragment struct {
+	FrameType         string
+	TableID           int `json:"TableId"`
+	FieldCount        int
+	TableFragmentType string
+	Rows              [][]interface{}
+}
+
+// TableProgress is a frame reporting the progress of a table in a progressive response.
+type TableProgress struct {
+	FrameType     string
+	TableID       int `json:"TableId"`
+	TableProgress float64
+}
+
+// TableCompletion is the frame ending a table started by a TableHeader.
+type TableCompletion struct {
+	FrameType    string
+	TableID      int `json:"TableId"`
+	RowCount     int
+	OneApiErrors []json.RawMessage
+}
+
+// DataSetCompletion is the last frame of a v2 response.
+type DataSetCompletion struct {
+	FrameType    string
+	HasErrors    bool
+	Cancelled    bool
+	OneApiErrors []json.RawMessage
+}
+
+// frameType is used to peek at the type of a frame before decoding it.
+type frameType struct {
+	FrameType string
+}
+
+// Decode reads a complete v2 response from r and returns the tables it holds.
+func Decode(r io.Reader) (*table.Dataset, error) {
+	return NewRowIterator(io.NopCloser(r)).ReadAll()
+}
+
+// parseOneAPIErrors parses the OneApi error payloads embedded in a frame or a row.
+func parseOneAPIErrors(raw []json.RawMessage) ([]*errors.KustoError, error) {
+	errs := make([]*errors.KustoError, 0, len(raw))
+	for _, b := range raw {
+		e, err := errors.ParseOneAPIError(b)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, e)
+	}
+	return errs, nil
+}
+
+// newRow builds a row from the raw JSON cells, choosing the value type from the column types.
+func newRow(cols table.Columns, cells []json.RawMessage) (*table.Row, error) {
+	if len(cells) != len(cols) {
+		return nil, fmt.Errorf("row has %d values, but table has %d columns", len(cells), len(cols))
+	}
+
+	row := &table.Row{
+		ColumnTypes: cols,
+		Values:      make(value.Values, 0, len(cells)),
+	}
+
+	for i, cell := range cells {
+		v, err := value.New(cols[i].Type)
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "column %q", cols[i].Name)
+		}
+		if err := v.(json.Unmarshaler).UnmarshalJSON(cell); err != nil {
+			return nil, errors.ErrWrapf(err, "column %q", cols[i].Name)
+		}
+		row.Values = append(row.Values, v)
+	}
+
+	return row, nil
+}
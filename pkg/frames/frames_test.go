@@ -0,0 +1,91 @@
+package frames
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+const v2Response = `[
+{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0"},
+{"FrameType":"DataTable","TableId":0,"TableKind":"QueryProperties","TableName":"@ExtendedProperties","Columns":[{"ColumnName":"TableId","ColumnType":"int"},{"ColumnName":"Key","ColumnType":"string"},{"ColumnName":"Value","ColumnType":"dynamic"}],"Rows":[[1,"Visualization","{\"Visualization\":null}"]]},
+{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"Timestamp","ColumnType":"datetime"},{"ColumnName":"Count","ColumnType":"long"},{"ColumnName":"Duration","ColumnType":"timespan"},{"ColumnName":"Ok","ColumnType":"bool"}],"Rows":[["2023-12-21T10:00:00.1234567Z",42,"1.02:03:04.5",true],[null,null,null,null]]},
+{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+func TestDecode(t *testing.T) {
+	ds, err := Decode(strings.NewReader(v2Response))
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+
+	if len(ds.Tables) != 2 {
+		t.Fatalf("got %d tables, want 2", len(ds.Tables))
+	}
+
+	primary := ds.PrimaryResults()
+	if len(primary) != 1 {
+		t.Fatalf("got %d primary results, want 1", len(primary))
+	}
+
+	tbl := primary[0]
+	if tbl.ID != 1 || tbl.Name != "PrimaryResult" || len(tbl.Rows) != 2 {
+		t.Fatalf("unexpected table: %+v", tbl)
+	}
+
+	row := tbl.Rows[0]
+	wantTime := time.Date(2023, 12, 21, 10, 0, 0, 123456700, time.UTC)
+	if got := row.Values[0].(*value.DateTime); !got.Valid || !got.Value.Equal(wantTime) {
+		t.Errorf("Timestamp = %v, want %v", got, wantTime)
+	}
+	if got := row.ValueByName("Count").(*value.Long); !got.Valid || got.Value != 42 {
+		t.Errorf("Count = %v, want 42", got)
+	}
+	wantSpan := 26*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Millisecond
+	if got := row.ValueByName("Duration").(*value.Timespan); !got.Valid || got.Value != wantSpan {
+		t.Errorf("Duration = %v, want %v", got.Value, wantSpan)
+	}
+	if got := row.ValueByName("Ok").(*value.Bool); !got.Valid || !got.Value {
+		t.Errorf("Ok = %v, want true", got)
+	}
+
+	for i, v := range tbl.Rows[1].Values {
+		if v.String() != "" {
+			t.Errorf("null value %d = %q, want empty", i, v.String())
+		}
+	}
+
+	if ds.TableByKind(table.KindQueryProperties) == nil {
+		t.Errorf("missing %s table", table.KindQueryProperties)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not json", body: `not json`},
+		{name: "missing completion", body: `[{"FrameType":"DataSetHeader","Version":"v2.0"}]`},
+		{name: "unknown frame", body: `[{"FrameType":"Unknown"},{"FrameType":"DataSetCompletion"}]`},
+		{
+			name: "row width mismatch",
+			body: `[{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"A","ColumnType":"long"}],"Rows":[[1,2]]},{"FrameType":"DataSetCompletion"}]`,
+		},
+		{
+			name: "type mismatch",
+			body: `[{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"A","ColumnType":"long"}],"Rows":[["x"]]},{"FrameType":"DataSetCompletion"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(test.body)); err == nil {
+				t.Errorf("Decode() succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,631 @@
+package frames
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+)
+
+// RowIterator incrementally decodes the frames of a v2 response and yields their rows one at a time,
+// so that results of any size can be processed in constant memory.
+//
+// Progressive responses are supported: rows of TableFragment frames are returned as they arrive, and
+// WithReplace and WithProgress report DataReplace fragments and table progress.
+//
+//	it := frames.NewRowIterator(body)
+//	defer it.Close()
+//	for it.Next() {
+//		row := it.Row()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RowIterator struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+
+	// fields holds the fields of the frame being read, other than its rows.
+	fields map[string]json.RawMessage
+
+	header     *DataSetHeader
+	completion *DataSetCompletion
+	stats      *table.QueryStats
+	table      *table.Table
+	row        *table.Row
+	err        error
+
+	// progressive holds the tables started by a TableHeader that have not completed yet.
+	progressive map[int]*table.Table
+
+	// progress, replace and tableStart are the callbacks set through IteratorOptions.
+	progress   func(p Progress)
+	replace    func(t *table.Table)
+	tableStart func(t *table.Table)
+	// lenient keeps iterating after a partial failure is reported.
+	lenient bool
+	// partial holds the partial failures reported so far.
+	partial []*errors.KustoError
+
+	// onTable, if set, is called every time a new table starts.
+	onTable func(t *table.Table)
+	// onReplace, if set, is called every time a fragment replaces the rows of a table.
+	onReplace func(t *table.Table)
+
+	started bool
+	inFrame bool
+	inRows  bool
+	done    bool
+	// tableStarted indicates the frame being read already started its table.
+	tableStarted bool
+}
+
+// Progress reports how far along a table of a progressive response is.
+type Progress struct {
+	// Table is the table the progress is reported for.
+	Table *table.Table
+	// Percentage is the completion percentage of the table, between 0 and 100.
+	Percentage float64
+}
+
+// IteratorOption configures a RowIterator.
+type IteratorOption func(it *RowIterator)
+
+// WithProgress sets a callback invoked with every TableProgress frame of a progressive response.
+func WithProgress(fn func(p Progress)) IteratorOption {
+	return func(it *RowIterator) {
+		it.progress = fn
+	}
+}
+
+// WithReplace sets a callback invoked when a DataReplace fragment is received for a table of a
+// progressive response. All the rows previously returned for that table must be discarded, and the
+// rows that follow replace them.
+func WithReplace(fn func(t *table.Table)) IteratorOption {
+	return func(it *RowIterator) {
+		it.replace = fn
+	}
+}
+
+// WithTableStart sets a callback invoked when a table of the response starts, before its rows are
+// returned. It is also invoked for the tables without rows, which Next never reaches.
+func WithTableStart(fn func(t *table.Table)) IteratorOption {
+	return func(it *RowIterator) {
+		it.tableStart = fn
+	}
+}
+
+// WithLenientPartialFailures keeps iterating when the service reports errors inside the response, so that
+// all the rows it holds are returned. Err returns the errors as a *errors.PartialFailureError once the
+// response is fully read. By default, the iteration stops at the first error reported.
+func WithLenientPartialFailures() IteratorOption {
+	return func(it *RowIterator) {
+		it.lenient = true
+	}
+}
+
+// NewRowIterator returns a RowIterator reading frames from body. The iterator takes ownership of body,
+// which is closed by Close.
+func NewRowIterator(body io.ReadCloser, options ...IteratorOption) *RowIterator {
+	it := &RowIterator{
+		body:        body,
+		dec:         json.NewDecoder(body),
+		progressive: map[int]*table.Table{},
+	}
+	// Keep numbers as json.Number, so that longs do not lose precision.
+	it.dec.UseNumber()
+
+	for _, option := range options {
+		option(it)
+	}
+
+	return it
+}
+
+// Next advances the iterator to the next row. It returns false when there are no more rows or an
+// error occurred, in which case Err returns it.
+func (it *RowIterator) Next() bool {
+	it.row = nil
+	for !it.done && it.err == nil {
+		var err error
+		switch {
+		case it.inRows:
+			var ok bool
+			ok, err = it.nextRow()
+			if ok {
+				return true
+			}
+		case it.inFrame:
+			err = it.readFields()
+		default:
+			err = it.nextFrame()
+		}
+		if err != nil {
+			it.err = err
+		}
+	}
+	return false
+}
+
+// Row returns the current row.
+func (it *RowIterator) Row() *table.Row {
+	return it.row
+}
+
+// Table returns the table the current row belongs to. Rows are not accumulated into the table.
+func (it *RowIterator) Table() *table.Table {
+	return it.table
+}
+
+// Header returns the DataSetHeader frame, or nil if it has not been read yet.
+func (it *RowIterator) Header() *DataSetHeader {
+	return it.header
+}
+
+// Completion returns the DataSetCompletion frame, or nil if it has not been read yet.
+func (it *RowIterator) Completion() *DataSetCompletion {
+	return it.completion
+}
+
+// Stats returns the resources consumed by the query, or nil if they have not been received yet. They are
+// reported in the QueryCompletionInformation table, near the end of the response.
+func (it *RowIterator) Stats() *table.QueryStats {
+	return it.stats
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *RowIterator) Err() error {
+	return it.err
+}
+
+// Close releases the underlying body. It is safe to call Close before the iteration is done.
+func (it *RowIterator) Close() error {
+	it.done = true
+	return it.body.Close()
+}
+
+// ReadAll consumes the remaining frames and returns the tables they hold, with all their rows. When the
+// service reports a partial failure, the tables received are returned along with a
+// *errors.PartialFailureError.
+func (it *RowIterator) ReadAll() (*table.Dataset, error) {
+	ds := &table.Dataset{}
+	it.onTable = func(t *table.Table) {
+		ds.Tables = append(ds.Tables, t)
+	}
+	it.onReplace = func(t *table.Table) {
+		t.Rows = nil
+	}
+
+	for it.Next() {
+		it.table.Rows = append(it.table.Rows, it.row)
+	}
+
+	ds.Stats = it.stats
+
+	var partial *errors.PartialFailureError
+	if stderrors.As(it.err, &partial) {
+		return ds, it.err
+	}
+	if it.err != nil {
+		return nil, it.err
+	}
+	return ds, nil
+}
+
+// nextFrame moves to the start of the next frame, or ends the iteration at the end of the frame array.
+func (it *RowIterator) nextFrame() error {
+	if !it.started {
+		if err := it.expectDelim('['); err != nil {
+			return err
+		}
+		it.started = true
+	}
+
+	if !it.dec.More() {
+		if err := it.expectDelim(']'); err != nil {
+			return err
+		}
+		it.done = true
+		if it.completion == nil {
+			return fmt.Errorf("response ended without a %s frame", TypeDataSetCompletion)
+		}
+		if len(it.partial) > 0 {
+			return &errors.PartialFailureError{Errors: it.partial}
+		}
+		return nil
+	}
+
+	if err := it.expectDelim('{'); err != nil {
+		return err
+	}
+	it.inFrame = true
+	it.tableStarted = false
+	it.fields = map[string]json.RawMessage{}
+	return nil
+}
+
+// readFields reads the fields of the current frame until either its rows or its end are reached.
+func (it *RowIterator) readFields() error {
+	for it.dec.More() {
+		tok, err := it.dec.Token()
+		if err != nil {
+			return errors.ErrWrapf(err, "failed to read frame")
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v in frame", tok)
+		}
+
+		if key == "Rows" {
+			return it.startRows()
+		}
+
+		var raw json.RawMessage
+		if err := it.dec.Decode(&raw); err != nil {
+			return errors.ErrWrapf(err, "failed to read frame field %q", key)
+		}
+		it.fields[key] = raw
+	}
+
+	if err := it.expectDelim('}'); err != nil {
+		return err
+	}
+	it.inFrame = false
+	return it.endFrame()
+}
+
+// startRows is called when the rows of a table frame are reached. All the fields describing the table
+// must precede its rows.
+func (it *RowIterator) startRows() error {
+	if it.tableStarted {
+		return fmt.Errorf("table %q has more than one set of rows", it.table.Name)
+	}
+
+	ft := frameType{}
+	if err := it.decodeFields(&ft); err != nil {
+		return err
+	}
+
+	switch ft.FrameType {
+	case TypeDataTable:
+		if err := it.startTable(); err != nil {
+			return err
+		}
+	case TypeTableFragment:
+		if err := it.startFragment(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unexpected rows in frame type %q", ft.FrameType)
+	}
+
+	if err := it.expectDelim('['); err != nil {
+		return err
+	}
+	it.inRows = true
+	return nil
+}
+
+// nextRow decodes the next row of the current table, if any.
+func (it *RowIterator) nextRow() (bool, error) {
+	if !it.dec.More() {
+		if err := it.expectDelim(']'); err != nil {
+			return false, err
+		}
+		it.inRows = false
+		return false, nil
+	}
+
+	tok, err := it.dec.Token()
+	if err != nil {
+		return false, errors.ErrWrapf(err, "failed to decode row of table %q", it.table.Name)
+	}
+
+	// Rows are arrays of values, or objects holding the errors that interrupted the table.
+	if tok == json.Delim('{') {
+		errs, err := it.readRowErrors()
+		if err != nil {
+			return false, err
+		}
+		return false, it.fail(errs)
+	}
+	if tok != json.Delim('[') {
+		return false, fmt.Errorf("unexpected token %v in rows of table %q", tok, it.table.Name)
+	}
+
+	var cells []json.RawMessage
+	for it.dec.More() {
+		var cell json.RawMessage
+		if err := it.dec.Decode(&cell); err != nil {
+			return false, errors.ErrWrapf(err, "failed to decode row of table %q", it.table.Name)
+		}
+		cells = append(cells, cell)
+	}
+	if err := it.expectDelim(']'); err != nil {
+		return false, err
+	}
+
+	row, err := newRow(it.table.Columns, cells)
+	if err != nil {
+		return false, errors.ErrWrapf(err, "table %q", it.table.Name)
+	}
+
+	if it.table.Kind == table.KindQueryCompletionInformation && it.stats == nil {
+		if it.stats, err = table.QueryStatsFromRow(row); err != nil {
+			return false, err
+		}
+	}
+
+	it.row = row
+	return true, nil
+}
+
+// readRowErrors reads the rest of a row object and returns the errors it holds.
+func (it *RowIterator) readRowErrors() ([]*errors.KustoError, error) {
+	var errs []*errors.KustoError
+	for it.dec.More() {
+		tok, err := it.dec.Token()
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "failed to read row of table %q", it.table.Name)
+		}
+
+		var raw []json.RawMessage
+		if err := it.dec.Decode(&raw); err != nil {
+			return nil, errors.ErrWrapf(err, "failed to read row of table %q", it.table.Name)
+		}
+		if tok != "OneApiErrors" {
+			continue
+		}
+		parsed, err := parseOneAPIErrors(raw)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, parsed...)
+	}
+
+	if err := it.expectDelim('}'); err != nil {
+		return nil, err
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("unexpected object in rows of table %q", it.table.Name)
+	}
+	return errs, nil
+}
+
+// fail records the partial failures reported by the service, ignoring the ones already recorded as the
+// DataSetCompletion frame repeats the errors of the tables. It returns the error stopping the iteration,
+// or nil when partial failures are lenient.
+func (it *RowIterator) fail(errs []*errors.KustoError) error {
+	for _, e := range errs {
+		if !it.recorded(e) {
+			it.partial = append(it.partial, e)
+		}
+	}
+	if it.lenient {
+		return nil
+	}
+	return &errors.PartialFailureError{Errors: it.partial}
+}
+
+// recorded reports whether an error identical to e was already recorded.
+func (it *RowIterator) recorded(e *errors.KustoError) bool {
+	for _, p := range it.partial {
+		if p.Code == e.Code && p.Message == e.Message && p.Description == e.Description {
+			return true
+		}
+	}
+	return false
+}
+
+// endFrame handles a frame once all its fields have been read.
+func (it *RowIterator) endFrame() error {
+	ft := frameType{}
+	if err := it.decodeFields(&ft); err != nil {
+		return err
+	}
+
+	switch ft.FrameType {
+	case TypeDataSetHeader:
+		if it.header != nil || it.table != nil {
+			return fmt.Errorf("%s must be the first frame", ft.FrameType)
+		}
+		it.header = &DataSetHeader{}
+		return it.decodeFields(it.header)
+	case TypeDataTable:
+		// Tables with rows were started when their rows were reached.
+		if !it.tableStarted {
+			return it.startTable()
+		}
+		return nil
+	case TypeTableHeader:
+		return it.startProgressiveTable()
+	case TypeTableFragment:
+		// Fragments with rows were started when their rows were reached.
+		if !it.tableStarted {
+			return it.startFragment()
+		}
+		return nil
+	case TypeTableProgress:
+		return it.reportProgress()
+	case TypeTableCompletion:
+		return it.completeProgressiveTable()
+	case TypeDataSetCompletion:
+		if len(it.progressive) > 0 {
+			return fmt.Errorf("%s received before all tables completed", ft.FrameType)
+		}
+		it.completion = &DataSetCompletion{}
+		if err := it.decodeFields(it.completion); err != nil {
+			return err
+		}
+		return it.completionErrors()
+	}
+	return fmt.Errorf("unsupported frame type %q", ft.FrameType)
+}
+
+// startTable makes the table described by the fields read so far the current table.
+func (it *RowIterator) startTable() error {
+	dt := DataTable{}
+	if err := it.decodeFields(&dt); err != nil {
+		return err
+	}
+
+	it.table = &table.Table{
+		ID:      dt.TableID,
+		Name:    dt.TableName,
+		Kind:    dt.TableKind,
+		Columns: dt.Columns,
+	}
+	it.tableStarted = true
+	it.startedTable(it.table)
+	return nil
+}
+
+// startProgressiveTable starts the table described by a TableHeader frame. Its rows are received in the
+// TableFragment frames that follow.
+func (it *RowIterator) startProgressiveTable() error {
+	th := TableHeader{}
+	if err := it.decodeFields(&th); err != nil {
+		return err
+	}
+	if _, ok := it.progressive[th.TableID]; ok {
+		return fmt.Errorf("table %d was already started", th.TableID)
+	}
+
+	t := &table.Table{
+		ID:      th.TableID,
+		Name:    th.TableName,
+		Kind:    th.TableKind,
+		Columns: th.Columns,
+	}
+	it.progressive[th.TableID] = t
+	it.startedTable(t)
+	return nil
+}
+
+// startedTable passes the table that started to the callbacks.
+func (it *RowIterator) startedTable(t *table.Table) {
+	if it.onTable != nil {
+		it.onTable(t)
+	}
+	if it.tableStart != nil {
+		it.tableStart(t)
+	}
+}
+
+// startFragment makes the table of the TableFragment frame being read the current table, discarding its
+// previous rows if the fragment replaces them.
+func (it *RowIterator) startFragment() error {
+	tf := TableFragment{}
+	if err := it.decodeFields(&tf); err != nil {
+		return err
+	}
+
+	t, ok := it.progressive[tf.TableID]
+	if !ok {
+		return fmt.Errorf("%s received for unknown table %d", TypeTableFragment, tf.TableID)
+	}
+	it.table = t
+	it.tableStarted = true
+
+	switch tf.TableFragmentType {
+	case FragmentDataAppend:
+	case FragmentDataReplace:
+		if it.onReplace != nil {
+			it.onReplace(t)
+		}
+		if it.replace != nil {
+			it.replace(t)
+		}
+	default:
+		return fmt.Errorf("unsupported table fragment type %q", tf.TableFragmentType)
+	}
+	return nil
+}
+
+// reportProgress passes the progress of a TableProgress frame to the progress callback.
+func (it *RowIterator) reportProgress() error {
+	tp := TableProgress{}
+	if err := it.decodeFields(&tp); err != nil {
+		return err
+	}
+
+	t, ok := it.progressive[tp.TableID]
+	if !ok {
+		return fmt.Errorf("%s received for unknown table %d", TypeTableProgress, tp.TableID)
+	}
+	if it.progress != nil {
+		it.progress(Progress{Table: t, Percentage: tp.TableProgress})
+	}
+	return nil
+}
+
+// completeProgressiveTable ends a table started by a TableHeader frame.
+func (it *RowIterator) completeProgressiveTable() error {
+	tc := TableCompletion{}
+	if err := it.decodeFields(&tc); err != nil {
+		return err
+	}
+
+	if _, ok := it.progressive[tc.TableID]; !ok {
+		return fmt.Errorf("%s received for unknown table %d", TypeTableCompletion, tc.TableID)
+	}
+	delete(it.progressive, tc.TableID)
+
+	if len(tc.OneApiErrors) == 0 {
+		return nil
+	}
+	errs, err := parseOneAPIErrors(tc.OneApiErrors)
+	if err != nil {
+		return err
+	}
+	return it.fail(errs)
+}
+
+// completionErrors records the errors reported by the DataSetCompletion frame.
+func (it *RowIterator) completionErrors() error {
+	if !it.completion.HasErrors && !it.completion.Cancelled {
+		return nil
+	}
+
+	errs, err := parseOneAPIErrors(it.completion.OneApiErrors)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) == 0 {
+		msg := "query completed with errors"
+		if it.completion.Cancelled {
+			msg = "query was cancelled"
+		}
+		errs = append(errs, &errors.KustoError{StatusCode: http.StatusOK, Message: msg})
+	}
+	return it.fail(errs)
+}
+
+// decodeFields decodes the fields read so far for the current frame into v.
+func (it *RowIterator) decodeFields(v interface{}) error {
+	b, err := json.Marshal(it.fields)
+	if err != nil {
+		return errors.ErrWrapf(err, "failed to read frame")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errors.ErrWrapf(err, "failed to decode frame")
+	}
+	return nil
+}
+
+// expectDelim reads the next token and checks it is the delimiter d.
+func (it *RowIterator) expectDelim(d json.Delim) error {
+	tok, err := it.dec.Token()
+	if err != nil {
+		return errors.ErrWrapf(err, "failed to read frames")
+	}
+	if got, ok := tok.(json.Delim); !ok || got != d {
+		return fmt.Errorf("expected %q, got %v", d, tok)
+	}
+	return nil
+}
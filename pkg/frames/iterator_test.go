@@ -0,0 +1,217 @@
+package frames
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+func TestRowIterator(t *testing.T) {
+	it := NewRowIterator(io.NopCloser(strings.NewReader(v2Response)))
+	defer it.Close()
+
+	var kinds []string
+	var counts []int64
+	for it.Next() {
+		kinds = append(kinds, it.Table().Kind)
+		if it.Table().Kind == "PrimaryResult" {
+			counts = append(counts, it.Row().ValueByName("Count").(*value.Long).Value)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	if got, want := strings.Join(kinds, ","), "QueryProperties,PrimaryResult,PrimaryResult"; got != want {
+		t.Errorf("got rows from %s, want %s", got, want)
+	}
+	if len(counts) != 2 || counts[0] != 42 || counts[1] != 0 {
+		t.Errorf("got counts %v, want [42 0]", counts)
+	}
+	if it.Header() == nil || it.Header().Version != "v2.0" {
+		t.Errorf("Header() = %+v", it.Header())
+	}
+	if it.Completion() == nil {
+		t.Errorf("Completion() = nil")
+	}
+}
+
+func TestRowIteratorEmptyTable(t *testing.T) {
+	body := `[{"FrameType":"DataSetHeader","Version":"v2.0"},` +
+		`{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"A","ColumnType":"long"}],"Rows":[]},` +
+		`{"FrameType":"DataTable","TableId":2,"TableKind":"QueryCompletionInformation","TableName":"QueryCompletionInformation","Columns":[]},` +
+		`{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}]`
+
+	ds, err := Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if len(ds.Tables) != 2 {
+		t.Fatalf("got %d tables, want 2", len(ds.Tables))
+	}
+	for _, tbl := range ds.Tables {
+		if len(tbl.Rows) != 0 {
+			t.Errorf("table %q has %d rows, want 0", tbl.Name, len(tbl.Rows))
+		}
+	}
+}
+
+func TestRowIteratorTableStart(t *testing.T) {
+	body := `[{"FrameType":"DataSetHeader","Version":"v2.0"},` +
+		`{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"Empty","Columns":[{"ColumnName":"A","ColumnType":"long"}],"Rows":[]},` +
+		`{"FrameType":"DataTable","TableId":2,"TableKind":"PrimaryResult","TableName":"Full","Columns":[{"ColumnName":"B","ColumnType":"long"}],"Rows":[[1]]},` +
+		`{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}]`
+
+	var started []string
+	it := NewRowIterator(io.NopCloser(strings.NewReader(body)), WithTableStart(func(tbl *table.Table) {
+		started = append(started, tbl.Name)
+	}))
+	defer it.Close()
+
+	if !it.Next() {
+		t.Fatalf("Next() returned false: %v", it.Err())
+	}
+	if len(started) != 2 || started[0] != "Empty" || started[1] != "Full" {
+		t.Errorf("tables started before the first row = %v, want [Empty Full]", started)
+	}
+	if it.Table().Name != "Full" {
+		t.Errorf("Table() = %q, want Full", it.Table().Name)
+	}
+	if it.Next() || it.Err() != nil {
+		t.Errorf("Next() returned a second row, or error %v", it.Err())
+	}
+
+	started = nil
+	it = NewRowIterator(io.NopCloser(strings.NewReader(progressiveResponse)), WithTableStart(func(tbl *table.Table) {
+		started = append(started, tbl.Name)
+	}))
+	defer it.Close()
+	for it.Next() {
+	}
+	if it.Err() != nil || len(started) != 1 {
+		t.Errorf("tables started of a progressive response = %v, error %v, want one table", started, it.Err())
+	}
+}
+
+func TestRowIteratorRowsBeforeColumns(t *testing.T) {
+	body := `[{"FrameType":"DataTable","Rows":[[1]],"Columns":[{"ColumnName":"A","ColumnType":"long"}]},{"FrameType":"DataSetCompletion"}]`
+
+	it := NewRowIterator(io.NopCloser(strings.NewReader(body)))
+	defer it.Close()
+	for it.Next() {
+		t.Fatalf("Next() returned a row, want error")
+	}
+	if it.Err() == nil {
+		t.Errorf("Err() = nil, want error")
+	}
+}
+
+const progressiveResponse = `[
+{"FrameType":"DataSetHeader","IsProgressive":true,"Version":"v2.0"},
+{"FrameType":"TableHeader","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"A","ColumnType":"long"}]},
+{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[1],[2]]},
+{"FrameType":"TableProgress","TableId":1,"TableProgress":50.5},
+{"FrameType":"TableFragment","TableFragmentType":"DataReplace","TableId":1,"Rows":[[3]]},
+{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[4]]},
+{"FrameType":"TableProgress","TableId":1,"TableProgress":100},
+{"FrameType":"TableCompletion","TableId":1,"RowCount":2},
+{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+func TestRowIteratorProgressive(t *testing.T) {
+	var progress []float64
+	replaced := 0
+
+	it := NewRowIterator(
+		io.NopCloser(strings.NewReader(progressiveResponse)),
+		WithProgress(func(p Progress) {
+			progress = append(progress, p.Percentage)
+		}),
+		WithReplace(func(tbl *table.Table) {
+			replaced++
+		}),
+	)
+
+	ds, err := it.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned error: %v", err)
+	}
+
+	if !it.Header().IsProgressive {
+		t.Errorf("Header().IsProgressive = false, want true")
+	}
+	if len(progress) != 2 || progress[0] != 50.5 || progress[1] != 100 {
+		t.Errorf("got progress %v, want [50.5 100]", progress)
+	}
+	if replaced != 1 {
+		t.Errorf("got %d replacements, want 1", replaced)
+	}
+
+	if len(ds.Tables) != 1 {
+		t.Fatalf("got %d tables, want 1", len(ds.Tables))
+	}
+	var got []int64
+	for _, row := range ds.Tables[0].Rows {
+		got = append(got, row.Values[0].(*value.Long).Value)
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("got rows %v, want [3 4]", got)
+	}
+}
+
+func TestRowIteratorProgressiveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "fragment for unknown table",
+			body: `[{"FrameType":"TableFragment","TableFragmentType":"DataAppend","TableId":1,"Rows":[[1]]},{"FrameType":"DataSetCompletion"}]`,
+		},
+		{
+			name: "unknown fragment type",
+			body: `[{"FrameType":"TableHeader","TableId":1,"Columns":[{"ColumnName":"A","ColumnType":"long"}]},{"FrameType":"TableFragment","TableFragmentType":"Other","TableId":1,"Rows":[]},{"FrameType":"TableCompletion","TableId":1},{"FrameType":"DataSetCompletion"}]`,
+		},
+		{
+			name: "table not completed",
+			body: `[{"FrameType":"TableHeader","TableId":1,"Columns":[]},{"FrameType":"DataSetCompletion"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(test.body)); err == nil {
+				t.Errorf("Decode() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestRowIteratorDynamicStrings(t *testing.T) {
+	body := `[{"FrameType":"DataSetHeader","Version":"v2.0"},` +
+		`{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"D","ColumnType":"dynamic"}],` +
+		`"Rows":[["123"],["true"],["null"],["{\"a\":1}"],[123],[{"a":"b"}],[null]]},` +
+		`{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}]`
+
+	it := NewRowIterator(io.NopCloser(strings.NewReader(body)))
+	defer it.Close()
+
+	want := []string{`"123"`, `"true"`, `"null"`, `"{\"a\":1}"`, `123`, `{"a":"b"}`, ``}
+	var got []string
+	for it.Next() {
+		d := it.Row().Values[0].(*value.Dynamic)
+		if d.Valid != (len(got) < len(want)-1) {
+			t.Errorf("row %d: Valid = %v", len(got), d.Valid)
+		}
+		got = append(got, string(d.Value))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got dynamic values %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package frames
+
+import (
+	stderrors "errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
+const partialResponse = `[
+{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0"},
+{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"A","ColumnType":"long"}],"Rows":[[1],[2],
+{"OneApiErrors":[{"error":{"code":"LimitsExceeded","message":"Request is invalid and cannot be executed.","@type":"Kusto.Data.Exceptions.KustoServicePartialQueryFailureLimitsExceededException","@message":"Query execution has exceeded the allowed limits","@permanent":false}}]},
+[3]]},
+{"FrameType":"DataSetCompletion","HasErrors":true,"Cancelled":false,"OneApiErrors":[{"error":{"code":"LimitsExceeded","message":"Request is invalid and cannot be executed.","@message":"Query execution has exceeded the allowed limits"}}]}
+]`
+
+func TestPartialFailureStrict(t *testing.T) {
+	ds, err := Decode(strings.NewReader(partialResponse))
+
+	var partial *errors.PartialFailureError
+	if !stderrors.As(err, &partial) {
+		t.Fatalf("Decode() returned %v, want a PartialFailureError", err)
+	}
+	if len(partial.Errors) != 1 || partial.Errors[0].Code != "LimitsExceeded" {
+		t.Errorf("unexpected errors: %+v", partial.Errors)
+	}
+	var kerr *errors.KustoError
+	if !stderrors.As(err, &kerr) || kerr.IsPermanent() {
+		t.Errorf("errors.As() = %+v, want the non permanent KustoError", kerr)
+	}
+
+	if ds == nil || len(ds.Tables) != 1 || len(ds.Tables[0].Rows) != 2 {
+		t.Fatalf("got dataset %+v, want the 2 rows received before the failure", ds)
+	}
+}
+
+func TestPartialFailureLenient(t *testing.T) {
+	it := NewRowIterator(io.NopCloser(strings.NewReader(partialResponse)), WithLenientPartialFailures())
+	ds, err := it.ReadAll()
+
+	var partial *errors.PartialFailureError
+	if !stderrors.As(err, &partial) {
+		t.Fatalf("ReadAll() returned %v, want a PartialFailureError", err)
+	}
+	if len(partial.Errors) != 1 {
+		t.Errorf("got %d errors, want the repeated error once", len(partial.Errors))
+	}
+	if ds == nil || len(ds.Tables[0].Rows) != 3 {
+		t.Fatalf("got dataset %+v, want all 3 rows", ds)
+	}
+}
+
+func TestPartialFailureCompletionOnly(t *testing.T) {
+	body := `[{"FrameType":"DataSetHeader","Version":"v2.0"},{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":true}]`
+	_, err := Decode(strings.NewReader(body))
+
+	var partial *errors.PartialFailureError
+	if !stderrors.As(err, &partial) || len(partial.Errors) != 1 {
+		t.Fatalf("Decode() returned %v, want a PartialFailureError", err)
+	}
+}
+
+func TestDecodeV1Exceptions(t *testing.T) {
+	body := `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"A","DataType":"Int64"}],` +
+		`"Rows":[[1],{"Exceptions":["Query execution has exceeded the allowed limits"]}]}]}`
+
+	ds, err := DecodeV1(strings.NewReader(body))
+
+	var partial *errors.PartialFailureError
+	if !stderrors.As(err, &partial) || len(partial.Errors) != 1 {
+		t.Fatalf("DecodeV1() returned %v, want a PartialFailureError", err)
+	}
+	if ds == nil || len(ds.Tables[0].Rows) != 1 {
+		t.Fatalf("got dataset %+v, want the row received", ds)
+	}
+}
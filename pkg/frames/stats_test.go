@@ -0,0 +1,55 @@
+package frames
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const statsResponse = `[
+{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0"},
+{"FrameType":"DataTable","TableId":1,"TableKind":"PrimaryResult","TableName":"PrimaryResult","Columns":[{"ColumnName":"A","ColumnType":"long"}],"Rows":[[1]]},
+{"FrameType":"DataTable","TableId":2,"TableKind":"QueryCompletionInformation","TableName":"QueryCompletionInformation","Columns":[
+{"ColumnName":"Timestamp","ColumnType":"datetime"},{"ColumnName":"ClientRequestId","ColumnType":"string"},{"ColumnName":"ActivityId","ColumnType":"guid"},
+{"ColumnName":"EventTypeName","ColumnType":"string"},{"ColumnName":"Payload","ColumnType":"string"}],"Rows":[
+["2023-12-21T10:00:00Z","KGC.execute;1","6ba7b810-9dad-11d1-80b4-00c04fd430c8","QueryInfo","{\"Count\":1}"],
+["2023-12-21T10:00:00Z","KGC.execute;1","6ba7b810-9dad-11d1-80b4-00c04fd430c8","QueryResourceConsumption","{\"ExecutionTime\":0.5,\"resource_usage\":{\"cache\":{\"memory\":{\"hits\":13,\"misses\":1,\"total\":14},\"disk\":{\"hits\":2,\"misses\":3,\"total\":5},\"shards\":{\"hot\":{\"hitbytes\":100,\"missbytes\":10,\"retrievebytes\":0},\"cold\":{\"hitbytes\":0,\"missbytes\":0,\"retrievebytes\":0},\"bypassbytes\":0}},\"cpu\":{\"user\":\"00:00:01.5\",\"kernel\":\"00:00:00\",\"total cpu\":\"00:00:01.5\"},\"memory\":{\"peak_per_node\":524384},\"network\":{\"inter_cluster_total_bytes\":962,\"cross_cluster_total_bytes\":0}},\"input_dataset_statistics\":{\"extents\":{\"total\":4,\"scanned\":2},\"rows\":{\"total\":100,\"scanned\":50}}}"]]},
+{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}
+]`
+
+func TestQueryStats(t *testing.T) {
+	ds, err := Decode(strings.NewReader(statsResponse))
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+
+	s := ds.Stats
+	if s == nil {
+		t.Fatalf("Stats = nil")
+	}
+	if s.ClientRequestID != "KGC.execute;1" || s.ActivityID != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Errorf("unexpected ids: %+v", s)
+	}
+	if s.ExecutionTime != 500*time.Millisecond || s.CPU.Total != 1500*time.Millisecond || s.CPU.User != 1500*time.Millisecond {
+		t.Errorf("unexpected times: %+v, %+v", s.ExecutionTime, s.CPU)
+	}
+	if s.MemoryPeakPerNode != 524384 || s.InterClusterBytes != 962 {
+		t.Errorf("unexpected memory and network: %+v", s)
+	}
+	if s.Cache.MemoryHits != 13 || s.Cache.MemoryMisses != 1 || s.Cache.DiskMisses != 3 || s.Cache.HotHitBytes != 100 {
+		t.Errorf("unexpected cache: %+v", s.Cache)
+	}
+	if s.Extents.Scanned != 2 || s.Extents.Total != 4 || s.Rows.Scanned != 50 || s.Rows.Total != 100 {
+		t.Errorf("unexpected scans: %+v, %+v", s.Extents, s.Rows)
+	}
+}
+
+func TestQueryStatsMissing(t *testing.T) {
+	ds, err := Decode(strings.NewReader(v2Response))
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if ds.Stats != nil {
+		t.Errorf("Stats = %+v, want nil", ds.Stats)
+	}
+}
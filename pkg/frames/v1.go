@@ -0,0 +1,204 @@
+package frames
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+)
+
+// v1DataTypes maps the .NET data types of v1 columns to Kusto column types, for columns that do not
+// carry their ColumnType.
+var v1DataTypes = map[string]types.Column{
+	"Boolean":    types.Bool,
+	"SByte":      types.Bool,
+	"DateTime":   types.DateTime,
+	"TimeSpan":   types.Timespan,
+	"Guid":       types.GUID,
+	"Int32":      types.Int,
+	"Int64":      types.Long,
+	"Double":     types.Real,
+	"Single":     types.Real,
+	"String":     types.String,
+	"Object":     types.Dynamic,
+	"Dynamic":    types.Dynamic,
+	"Decimal":    types.Decimal,
+	"SqlDecimal": types.Decimal,
+}
+
+// V1Response is the response of the v1 endpoints, such as /v1/rest/mgmt.
+type V1Response struct {
+	Tables []V1Table
+}
+
+// V1Table is a table of a v1 response. Rows are arrays of values, or objects holding the exceptions that
+// interrupted the table.
+type V1Table struct {
+	TableName string
+	Columns   []V1Column
+	Rows      []json.RawMessage
+}
+
+// v1RowErrors is a row of a v1 table reporting errors.
+type v1RowErrors struct {
+	Exceptions   []string
+	OneApiErrors []json.RawMessage
+}
+
+// V1Column is a column of a v1 table.
+type V1Column struct {
+	ColumnName string
+	DataType   string
+	ColumnType string
+}
+
+// DecodeV1 reads a v1 response from r and returns the tables it holds. All the tables are returned as
+// primary results. When tables hold errors, the rows received are returned along with a
+// *errors.PartialFailureError.
+func DecodeV1(r io.Reader) (*table.Dataset, error) {
+	dec := json.NewDecoder(r)
+	// Keep numbers as json.Number, so that longs do not lose precision.
+	dec.UseNumber()
+
+	resp := V1Response{}
+	if err := dec.Decode(&resp); err != nil {
+		return nil, errors.ErrWrapf(err, "failed to decode v1 response")
+	}
+
+	ds := &table.Dataset{}
+	var partial []*errors.KustoError
+	for i, vt := range resp.Tables {
+		t, errs, err := vt.table(i)
+		if err != nil {
+			return nil, err
+		}
+		ds.Tables = append(ds.Tables, t)
+		partial = append(partial, errs...)
+	}
+
+	if len(partial) > 0 {
+		return ds, &errors.PartialFailureError{Errors: partial}
+	}
+	return ds, nil
+}
+
+// Table converts the v1 table into a table.Table with the given id. Rows reporting errors cause an error.
+func (vt V1Table) Table(id int) (*table.Table, error) {
+	t, errs, err := vt.table(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, &errors.PartialFailureError{Errors: errs}
+	}
+	return t, nil
+}
+
+// table converts the v1 table into a table.Table with the given id, returning the errors reported by its
+// rows separately.
+func (vt V1Table) table(id int) (*table.Table, []*errors.KustoError, error) {
+	cols := make(table.Columns, 0, len(vt.Columns))
+	for _, vc := range vt.Columns {
+		col, err := vc.Column()
+		if err != nil {
+			return nil, nil, errors.ErrWrapf(err, "table %q", vt.TableName)
+		}
+		cols = append(cols, col)
+	}
+
+	t := &table.Table{
+		ID:      id,
+		Name:    vt.TableName,
+		Kind:    table.KindPrimaryResult,
+		Columns: cols,
+		Rows:    make([]*table.Row, 0, len(vt.Rows)),
+	}
+
+	var partial []*errors.KustoError
+	for i, raw := range vt.Rows {
+		if isObject(raw) {
+			errs, err := v1Errors(raw)
+			if err != nil {
+				return nil, nil, errors.ErrWrapf(err, "table %q row %d", vt.TableName, i)
+			}
+			partial = append(partial, errs...)
+			continue
+		}
+
+		var cells []json.RawMessage
+		if err := json.Unmarshal(raw, &cells); err != nil {
+			return nil, nil, errors.ErrWrapf(err, "table %q row %d", vt.TableName, i)
+		}
+		for j, col := range cols {
+			if col.Type == types.Dynamic && j < len(cells) {
+				cells[j] = v1Dynamic(cells[j])
+			}
+		}
+		row, err := newRow(cols, cells)
+		if err != nil {
+			return nil, nil, errors.ErrWrapf(err, "table %q row %d", vt.TableName, i)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+
+	return t, partial, nil
+}
+
+// v1Errors returns the errors of a v1 row reporting errors.
+func v1Errors(raw json.RawMessage) ([]*errors.KustoError, error) {
+	re := v1RowErrors{}
+	if err := json.Unmarshal(raw, &re); err != nil {
+		return nil, errors.ErrWrapf(err, "failed to decode row errors")
+	}
+
+	errs, err := parseOneAPIErrors(re.OneApiErrors)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range re.Exceptions {
+		errs = append(errs, &errors.KustoError{StatusCode: http.StatusOK, Message: msg})
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("unexpected object row: %s", string(raw))
+	}
+	return errs, nil
+}
+
+// v1Dynamic returns the JSON of a dynamic cell of a v1 table. The v1 endpoints send dynamic values as
+// strings holding their JSON, which is returned unquoted; other cells are returned as is.
+func v1Dynamic(raw json.RawMessage) json.RawMessage {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil || !json.Valid([]byte(s)) {
+		return raw
+	}
+	return json.RawMessage(s)
+}
+
+// isObject reports whether raw holds a JSON object.
+func isObject(raw json.RawMessage) bool {
+	b := bytes.TrimSpace(raw)
+	return len(b) > 0 && b[0] == '{'
+}
+
+// Column converts the v1 column into a table.Column, using its ColumnType or, if missing, its DataType.
+func (vc V1Column) Column() (table.Column, error) {
+	t := types.Column(vc.ColumnType)
+	if vc.ColumnType == "" {
+		var ok bool
+		if t, ok = v1DataTypes[vc.DataType]; !ok {
+			return table.Column{}, fmt.Errorf("column %q has unsupported data type %q", vc.ColumnName, vc.DataType)
+		}
+	}
+
+	if !t.IsValid() {
+		return table.Column{}, fmt.Errorf("column %q has unsupported type %q", vc.ColumnName, t)
+	}
+
+	return table.Column{Name: vc.ColumnName, Type: t}, nil
+}
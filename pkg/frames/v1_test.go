@@ -0,0 +1,68 @@
+package frames
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+func TestDecodeV1(t *testing.T) {
+	body := `{"Tables":[{"TableName":"Table_0","Columns":[
+		{"ColumnName":"TableName","DataType":"String","ColumnType":"string"},
+		{"ColumnName":"Size","DataType":"Int64"},
+		{"ColumnName":"Enabled","DataType":"SByte"},
+		{"ColumnName":"Policy","DataType":"Object","ColumnType":"dynamic"}
+	],"Rows":[["t1",9007199254740993,1,"{\"a\":1}"],["t2",null,0,null]]}]}`
+
+	ds, err := DecodeV1(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("DecodeV1() returned error: %v", err)
+	}
+
+	tables := ds.PrimaryResults()
+	if len(tables) != 1 || tables[0].Name != "Table_0" || len(tables[0].Rows) != 2 {
+		t.Fatalf("unexpected tables: %+v", tables)
+	}
+
+	tbl := tables[0]
+	if tbl.Columns[1].Type != types.Long || tbl.Columns[2].Type != types.Bool {
+		t.Errorf("unexpected column types: %+v", tbl.Columns)
+	}
+
+	row := tbl.Rows[0]
+	if got := row.ValueByName("Size").(*value.Long).Value; got != 9007199254740993 {
+		t.Errorf("Size = %d, want 9007199254740993", got)
+	}
+	if got := row.ValueByName("Enabled").(*value.Bool); !got.Value {
+		t.Errorf("Enabled = %+v, want true", got)
+	}
+	if got := row.ValueByName("Policy").String(); got != `{"a":1}` {
+		t.Errorf("Policy = %s", got)
+	}
+}
+
+func TestDecodeV1UnsupportedType(t *testing.T) {
+	body := `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"A","DataType":"Byte[]"}],"Rows":[]}]}`
+	if _, err := DecodeV1(strings.NewReader(body)); err == nil {
+		t.Errorf("DecodeV1() succeeded, want error")
+	}
+}
+
+func TestDecodeV1DynamicStrings(t *testing.T) {
+	body := `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"D","ColumnType":"dynamic"}],` +
+		`"Rows":[["\"s\""],["[1,2]"],["not json"],[{"a":1}]]}]}`
+
+	ds, err := DecodeV1(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("DecodeV1() returned error: %v", err)
+	}
+
+	want := []string{`"s"`, `[1,2]`, `"not json"`, `{"a":1}`}
+	for i, row := range ds.Tables[0].Rows {
+		if got := row.Values[0].String(); got != want[i] {
+			t.Errorf("row %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Package cloudcache holds the process-wide cache of the cloud information of Kusto endpoints, keyed by
+// endpoint host. It is used by package conn, and reset by package kustotest when a fake server changes the
+// cloud information it serves.
+package cloudcache
+
+import "sync"
+
+var cache sync.Map
+
+// Load returns the cloud information cached for host.
+func Load(host string) (interface{}, bool) {
+	return cache.Load(host)
+}
+
+// LoadOrStore caches ci for host, unless cloud information is already cached for it, and returns the
+// cached cloud information.
+func LoadOrStore(host string, ci interface{}) interface{} {
+	actual, _ := cache.LoadOrStore(host, ci)
+	return actual
+}
+
+// Forget removes the cloud information cached for host, so that it is queried again.
+func Forget(host string) {
+	cache.Delete(host)
+}
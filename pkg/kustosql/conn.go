@@ -0,0 +1,278 @@
+package kustosql
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/client"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+	"github.com/google/uuid"
+)
+
+// ErrTransactionsNotSupported is returned when beginning a transaction, as Kusto has none.
+var ErrTransactionsNotSupported = stderrors.New("kustosql: transactions are not supported")
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.StmtQueryContext   = (*stmt)(nil)
+	_ driver.StmtExecContext    = (*stmt)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+)
+
+// paramTypes holds the Kusto type of query parameters of each Go type, including the value types.
+var paramTypes = map[reflect.Type]types.Column{
+	reflect.TypeOf(false):            types.Bool,
+	reflect.TypeOf(int32(0)):         types.Int,
+	reflect.TypeOf(int64(0)):         types.Long,
+	reflect.TypeOf(float64(0)):       types.Real,
+	reflect.TypeOf(""):               types.String,
+	reflect.TypeOf(time.Time{}):      types.DateTime,
+	reflect.TypeOf(time.Duration(0)): types.Timespan,
+	reflect.TypeOf(uuid.UUID{}):      types.GUID,
+	reflect.TypeOf([]byte{}):         types.Dynamic,
+	reflect.TypeOf(&big.Float{}):     types.Decimal,
+	reflect.TypeOf(&big.Int{}):       types.Decimal,
+	reflect.TypeOf(value.Bool{}):     types.Bool,
+	reflect.TypeOf(value.Int{}):      types.Int,
+	reflect.TypeOf(value.Long{}):     types.Long,
+	reflect.TypeOf(value.Real{}):     types.Real,
+	reflect.TypeOf(value.String{}):   types.String,
+	reflect.TypeOf(value.DateTime{}): types.DateTime,
+	reflect.TypeOf(value.Timespan{}): types.Timespan,
+	reflect.TypeOf(value.GUID{}):     types.GUID,
+	reflect.TypeOf(value.Dynamic{}):  types.Dynamic,
+	reflect.TypeOf(value.Decimal{}):  types.Decimal,
+}
+
+// paramType returns the Kusto type of the query parameter value v.
+func paramType(v interface{}) (types.Column, bool) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return "", false
+	}
+	if _, ok := v.(value.Value); ok && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	ct, ok := paramTypes[t]
+	return ct, ok
+}
+
+// conn is a connection to a cluster. It holds no state of its own: requests are sent by the client shared
+// by the connections of a Connector.
+type conn struct {
+	client *client.Client
+}
+
+// Prepare implements driver.Conn. The statement is only sent when it is run.
+func (c *conn) Prepare(csl string) (driver.Stmt, error) {
+	return &stmt{conn: c, csl: csl}, nil
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *conn) PrepareContext(ctx context.Context, csl string) (driver.Stmt, error) {
+	return c.Prepare(csl)
+}
+
+// Close implements driver.Conn.
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin implements driver.Conn. It returns ErrTransactionsNotSupported.
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, ErrTransactionsNotSupported
+}
+
+// BeginTx implements driver.ConnBeginTx. It returns ErrTransactionsNotSupported.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return nil, ErrTransactionsNotSupported
+}
+
+// Ping implements driver.Pinger.
+func (c *conn) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx)
+}
+
+// CheckNamedValue implements driver.NamedValueChecker. It accepts the values that have a Kusto type, including
+// the values wrapped by value.SQL, and the values database/sql converts to one.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if sv, ok := nv.Value.(value.SQLValue); ok && sv.Kusto != nil {
+		nv.Value = sv.Kusto
+	}
+	if _, ok := paramType(nv.Value); ok {
+		return nil
+	}
+
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return errors.ErrWrapf(errors.ErrInvalidType, "query parameter %q cannot be null", nv.Name)
+	}
+	if _, ok := paramType(v); !ok {
+		return errors.ErrWrapf(errors.ErrInvalidType, "query parameter %q has unsupported type %T", nv.Name, nv.Value)
+	}
+	nv.Value = v
+	return nil
+}
+
+// QueryContext implements driver.QueryerContext. The rows of queries are read from the response as they are
+// scanned, while the results of management commands are read at once.
+func (c *conn) QueryContext(ctx context.Context, csl string, args []driver.NamedValue) (driver.Rows, error) {
+	if isMgmt(csl) {
+		ds, err := c.mgmt(ctx, csl, args)
+		if err != nil {
+			return nil, err
+		}
+		return newTableRows(ds.PrimaryResults()), nil
+	}
+
+	options, err := queryOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(ctx, c.client, csl, options)
+}
+
+// ExecContext implements driver.ExecerContext. The results of the statement are discarded.
+func (c *conn) ExecContext(ctx context.Context, csl string, args []driver.NamedValue) (driver.Result, error) {
+	if isMgmt(csl) {
+		if _, err := c.mgmt(ctx, csl, args); err != nil {
+			return nil, err
+		}
+		return driver.ResultNoRows, nil
+	}
+
+	options, err := queryOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.client.Query(ctx, "", csl, options...); err != nil {
+		return nil, err
+	}
+	return driver.ResultNoRows, nil
+}
+
+// mgmt runs the management command csl, which takes no arguments.
+func (c *conn) mgmt(ctx context.Context, csl string, args []driver.NamedValue) (*table.Dataset, error) {
+	if len(args) > 0 {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "management commands do not take arguments")
+	}
+	return c.client.Mgmt(ctx, "", csl)
+}
+
+// queryOptions returns the options of a query taking args as query parameters.
+func queryOptions(args []driver.NamedValue) ([]query.QueryOption, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	params, err := parameters(args)
+	if err != nil {
+		return nil, err
+	}
+	return []query.QueryOption{query.QueryParameters(params)}, nil
+}
+
+// isMgmt reports whether csl is a management command.
+func isMgmt(csl string) bool {
+	return strings.HasPrefix(strings.TrimSpace(csl), ".")
+}
+
+// parameters returns the query parameters holding args, typed after their values.
+func parameters(args []driver.NamedValue) (*query.Parameters, error) {
+	paramTypes := make(query.ParamTypes, len(args))
+	values := make(map[string]interface{}, len(args))
+
+	for _, arg := range args {
+		if arg.Name == "" {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "argument %d has no name: arguments must be named with sql.Named", arg.Ordinal)
+		}
+		t, ok := paramType(arg.Value)
+		if !ok {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "query parameter %q has unsupported type %T", arg.Name, arg.Value)
+		}
+		paramTypes[arg.Name] = query.ParamType{Type: t}
+		values[arg.Name] = kustoValue(arg.Value)
+	}
+
+	params, err := query.NewParameters(paramTypes)
+	if err != nil {
+		return nil, err
+	}
+	return params.With(values)
+}
+
+// kustoValue returns a pointer to v when v is a value type, such as value.Long, as only pointers to them
+// implement value.Value. Other values are returned as is.
+func kustoValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Struct {
+		return v
+	}
+	p := reflect.New(rv.Type())
+	p.Elem().Set(rv)
+	if kv, ok := p.Interface().(value.Value); ok {
+		return kv
+	}
+	return v
+}
+
+// stmt is a statement, sent when it is run.
+type stmt struct {
+	conn *conn
+	csl  string
+}
+
+// Close implements driver.Stmt.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput implements driver.Stmt. It returns -1, as the number of arguments is not known.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec implements driver.Stmt.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query implements driver.Stmt.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.csl, args)
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.csl, args)
+}
+
+// namedValues returns args as positional named values.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nvs
+}
@@ -0,0 +1,89 @@
+// Package kustosql provides a database/sql driver for Kusto, registered as "kusto". Its data source names
+// are connection strings, as parsed by client.ParseConnectionString:
+//
+//	db, err := sql.Open("kusto", "Data Source=https://cluster.kusto.windows.net;Initial Catalog=db;AAD Federated Security=True")
+//	...
+//	rows, err := db.QueryContext(ctx, "T | where Name == name | take 10", sql.Named("name", "a"))
+//
+// Queries run against the Initial Catalog of the connection string. Arguments must be named with
+// sql.Named: they are declared as query parameters with `declare query_parameters`, with the Kusto type of
+// their Go type, and their values are sent in the request properties. The value types, and values wrapped
+// by value.SQL, are accepted as arguments. Statements starting with a dot are run as management commands,
+// and take no arguments.
+//
+// Rows are read from the primary result tables of the response, each table being a result set. The rows of
+// queries are read from the response as they are scanned, so that results of any size are scanned in
+// constant memory, and closing the rows releases the response. Their values are scanned as bool, int64,
+// float64, string, time.Time, time.Duration for timespans, []byte holding the JSON of dynamic values, and
+// string for guids and decimals. Null values are scanned as nil. Values can also be scanned into the value
+// types, such as value.Long, whose Valid field is false for null values.
+//
+// Kusto has no transactions: Begin and BeginTx return ErrTransactionsNotSupported.
+package kustosql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/crodriguezde/go-kusto/pkg/client"
+)
+
+// DriverName is the name the driver is registered with.
+const DriverName = "kusto"
+
+func init() {
+	sql.Register(DriverName, Driver{})
+}
+
+var (
+	_ driver.Driver        = Driver{}
+	_ driver.DriverContext = Driver{}
+	_ driver.Connector     = (*Connector)(nil)
+)
+
+// Driver is the database/sql driver for Kusto.
+type Driver struct{}
+
+// Open implements driver.Driver. It returns a connection to the cluster of the connection string dsn.
+func (d Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector implements driver.DriverContext.
+func (Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	return NewConnector(dsn)
+}
+
+// Connector opens connections to a cluster, sharing a single client.Client. It is used with sql.OpenDB.
+type Connector struct {
+	client *client.Client
+}
+
+// NewConnector returns a Connector for the cluster of the connection string dsn. options are applied to
+// the client after the settings of dsn, to configure its transport or credential:
+//
+//	c, err := kustosql.NewConnector(dsn, client.WithHttp(httpClient))
+//	...
+//	db := sql.OpenDB(c)
+func NewConnector(dsn string, options ...client.ClientOption) (*Connector, error) {
+	c, err := client.NewFromConnectionString(dsn, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{client: c}, nil
+}
+
+// Connect implements driver.Connector. It does not send any request.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{client: c.client}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *Connector) Driver() driver.Driver {
+	return Driver{}
+}
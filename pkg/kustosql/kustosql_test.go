@@ -0,0 +1,416 @@
+package kustosql_test
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/client"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/kustosql"
+	"github.com/crodriguezde/go-kusto/pkg/kustotest"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+	"github.com/google/uuid"
+)
+
+var allTypes = kustotest.Table{
+	Columns: table.Columns{
+		{Name: "b", Type: types.Bool},
+		{Name: "d", Type: types.DateTime},
+		{Name: "dyn", Type: types.Dynamic},
+		{Name: "g", Type: types.GUID},
+		{Name: "i", Type: types.Int},
+		{Name: "l", Type: types.Long},
+		{Name: "r", Type: types.Real},
+		{Name: "s", Type: types.String},
+		{Name: "t", Type: types.Timespan},
+		{Name: "dec", Type: types.Decimal},
+	},
+	Rows: [][]interface{}{
+		{true, "2024-01-02T03:04:05Z", map[string]interface{}{"a": 1}, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", 1, 2, 1.5, "s", "01:00:00", "1.25"},
+		{nil, nil, nil, nil, nil, nil, nil, "", nil, nil},
+	},
+}
+
+// openDB returns a database of the server, with db as default database.
+func openDB(t *testing.T, srv *kustotest.Server) *sql.DB {
+	t.Helper()
+
+	c, err := kustosql.NewConnector("Data Source="+srv.URL+";Initial Catalog=db;User Token="+kustotest.Token, client.WithHttp(srv.Client()))
+	if err != nil {
+		t.Fatalf("NewConnector() returned error: %v", err)
+	}
+	db := sql.OpenDB(c)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestQuery(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^T`, kustotest.V2(allTypes))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T")
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatalf("ColumnTypes() returned error: %v", err)
+	}
+	var names []string
+	for _, ct := range cts {
+		names = append(names, ct.DatabaseTypeName())
+	}
+	if got, want := strings.Join(names, ","), "BOOL,DATETIME,DYNAMIC,GUID,INT,LONG,REAL,STRING,TIMESPAN,DECIMAL"; got != want {
+		t.Errorf("DatabaseTypeName() = %s, want %s", got, want)
+	}
+
+	var (
+		b   bool
+		d   time.Time
+		dyn []byte
+		g   uuid.UUID
+		i   int32
+		l   int64
+		r   float64
+		s   string
+		ts  time.Duration
+		dec string
+	)
+	if !rows.Next() {
+		t.Fatalf("Next() returned false: %v", rows.Err())
+	}
+	if err := rows.Scan(&b, &d, &dyn, &g, &i, &l, &r, &s, &ts, &dec); err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+	if !b || !d.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) || string(dyn) != `{"a":1}` ||
+		g.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" || i != 1 || l != 2 || r != 1.5 || s != "s" ||
+		ts != time.Hour || dec != "1.25" {
+		t.Errorf("Scan() got %v %v %s %v %v %v %v %v %v %v", b, d, dyn, g, i, l, r, s, ts, dec)
+	}
+
+	var (
+		nb  sql.NullBool
+		nd  sql.NullTime
+		nl  sql.NullInt64
+		ns  sql.NullString
+		any interface{}
+	)
+	if !rows.Next() {
+		t.Fatalf("Next() returned false: %v", rows.Err())
+	}
+	if err := rows.Scan(&nb, &nd, &any, &ns, &nl, &nl, &sql.NullFloat64{}, &s, &any, &ns); err != nil {
+		t.Fatalf("Scan() of nulls returned error: %v", err)
+	}
+	if nb.Valid || nd.Valid || nl.Valid || ns.Valid || any != nil {
+		t.Errorf("Scan() of nulls got %v %v %v %v %v", nb, nd, nl, ns, any)
+	}
+
+	if rows.Next() {
+		t.Errorf("Next() returned a third row")
+	}
+}
+
+func TestQueryNamedArgs(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `T`, kustotest.V2(kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.Long}}}))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T | where Name == name and Count > count and Timestamp > ago(age)",
+		sql.Named("name", "a"),
+		sql.Named("count", 3),
+		sql.Named("age", time.Hour),
+		sql.Named("id", value.GUID{Value: uuid.Nil, Valid: true}),
+	)
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	if rows.Next() {
+		t.Errorf("Next() returned a row of an empty table")
+	}
+	rows.Close()
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if want := "declare query_parameters(age:timespan, count:long, id:guid, name:string);\n"; !strings.HasPrefix(r.CSL, want) {
+		t.Errorf("CSL = %q, want the prefix %q", r.CSL, want)
+	}
+	want := map[string]string{"age": "timespan(01:00:00)", "count": "long(3)", "id": "guid(00000000-0000-0000-0000-000000000000)", "name": `"a"`}
+	for name, v := range want {
+		if r.Parameters[name] != v {
+			t.Errorf("parameter %s = %q, want %q", name, r.Parameters[name], v)
+		}
+	}
+}
+
+func TestQueryInvalidArgs(t *testing.T) {
+	srv := kustotest.New(t)
+	db := openDB(t, srv)
+
+	tests := []struct {
+		name string
+		args []interface{}
+	}{
+		{name: "positional", args: []interface{}{1}},
+		{name: "null", args: []interface{}{sql.Named("x", nil)}},
+		{name: "unsupported type", args: []interface{}{sql.Named("x", struct{}{})}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := db.QueryContext(context.Background(), "T", test.args...); err == nil {
+				t.Errorf("QueryContext() returned no error")
+			}
+		})
+	}
+
+	if len(srv.Requests()) != 0 {
+		t.Errorf("server got %d requests, want 0", len(srv.Requests()))
+	}
+}
+
+func TestMultipleResultSets(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^T`, kustotest.V2(
+		kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.Long}}, Rows: [][]interface{}{{1}}},
+		kustotest.Table{Columns: table.Columns{{Name: "y", Type: types.String}}, Rows: [][]interface{}{{"a"}}},
+	))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T")
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for {
+		cols, _ := rows.Columns()
+		for rows.Next() {
+			var s string
+			if err := rows.Scan(&s); err != nil {
+				t.Fatalf("Scan() returned error: %v", err)
+			}
+			got = append(got, cols[0]+"="+s)
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if strings.Join(got, ",") != "x=1,y=a" {
+		t.Errorf("rows = %v, want x=1,y=a", got)
+	}
+}
+
+func TestExecMgmt(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^\.create table`, kustotest.V1())
+	db := openDB(t, srv)
+
+	if _, err := db.ExecContext(context.Background(), ".create table T (x:long)"); err != nil {
+		t.Fatalf("ExecContext() returned error: %v", err)
+	}
+	if _, err := db.ExecContext(context.Background(), ".create table T (x:long)", sql.Named("x", 1)); err == nil {
+		t.Errorf("ExecContext() of a command with arguments returned no error")
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Path != kustotest.MgmtPath || reqs[0].DB != "db" {
+		t.Errorf("unexpected requests: %+v", reqs)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	db := openDB(t, kustotest.New(t))
+
+	if _, err := db.Begin(); !stderrors.Is(err, kustosql.ErrTransactionsNotSupported) {
+		t.Errorf("Begin() returned error %v, want ErrTransactionsNotSupported", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := sql.Open(kustosql.DriverName, "Data Source=https://cluster.kusto.windows.net;Unknown=1"); err == nil {
+		t.Errorf("Open() of an invalid connection string returned no error")
+	}
+
+	db, err := sql.Open(kustosql.DriverName, "Data Source=https://cluster.kusto.windows.net;Initial Catalog=db;User Token=token")
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	db.Close()
+}
+
+func TestValueTypes(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `T`, kustotest.V2(allTypes))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T | where Timestamp > ago(age)",
+		sql.Named("age", value.SQL(&value.Timespan{Value: time.Minute, Valid: true})))
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		b   value.Bool
+		d   value.DateTime
+		dyn value.Dynamic
+		g   value.GUID
+		i   value.Int
+		l   value.Long
+		r   value.Real
+		s   value.String
+		ts  value.Timespan
+		dec value.Decimal
+	)
+	for n := 0; rows.Next(); n++ {
+		if err := rows.Scan(&b, &d, &dyn, &g, &i, &l, &r, &s, &ts, &dec); err != nil {
+			t.Fatalf("Scan() returned error: %v", err)
+		}
+		if n == 0 && (!b.Value || string(dyn.Value) != `{"a":1}` || g.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" ||
+			i.Value != 1 || l.Value != 2 || ts.Value != time.Hour || dec.Value != "1.25") {
+			t.Errorf("Scan() got %v %v %v %v %v %v %v %v %v %v", b, d, dyn, g, i, l, r, s, ts, dec)
+		}
+		if n == 1 && (b.Valid || d.Valid || dyn.Valid || g.Valid || l.Valid || !s.Valid || ts.Valid || dec.Valid) {
+			t.Errorf("Scan() of nulls got valid values")
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Next() returned error: %v", err)
+	}
+
+	if p := srv.Requests()[0].Parameters["age"]; p != "timespan(00:01:00)" {
+		t.Errorf("parameter age = %q, want timespan(00:01:00)", p)
+	}
+}
+
+func TestEmptyResultSets(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^T`, kustotest.V2(
+		kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.Long}}},
+		kustotest.Table{Columns: table.Columns{{Name: "y", Type: types.String}}, Rows: [][]interface{}{{"a"}, {"b"}}},
+		kustotest.Table{Columns: table.Columns{{Name: "z", Type: types.Real}}},
+	))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T")
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for {
+		cols, err := rows.Columns()
+		if err != nil {
+			t.Fatalf("Columns() returned error: %v", err)
+		}
+		got = append(got, cols[0]+":")
+		for rows.Next() {
+			var s string
+			if err := rows.Scan(&s); err != nil {
+				t.Fatalf("Scan() returned error: %v", err)
+			}
+			got = append(got, s)
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if strings.Join(got, ",") != "x:,y:,a,b,z:" {
+		t.Errorf("result sets = %v, want x:,y:,a,b,z:", got)
+	}
+}
+
+func TestQueryPartialFailure(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^T`, kustotest.PartialFailure("LimitsExceeded", "Query result set has exceeded the internal record count limit",
+		kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.Long}}, Rows: [][]interface{}{{1}, {2}}}))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T")
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	var partial *errors.PartialFailureError
+	if !stderrors.As(rows.Err(), &partial) {
+		t.Errorf("Err() = %v, want a partial failure", rows.Err())
+	}
+	if n != 2 {
+		t.Errorf("got %d rows before the failure, want 2", n)
+	}
+}
+
+func TestQueryCloseEarly(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^T`, kustotest.V2(kustotest.Table{
+		Columns: table.Columns{{Name: "x", Type: types.Long}},
+		Rows:    [][]interface{}{{1}, {2}, {3}},
+	}))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), "T")
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	if !rows.Next() {
+		t.Fatalf("Next() returned false: %v", rows.Err())
+	}
+	if err := rows.Close(); err != nil {
+		t.Errorf("Close() returned error: %v", err)
+	}
+	if rows.Next() {
+		t.Errorf("Next() returned a row after Close()")
+	}
+}
+
+func TestQueryMgmt(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^\.show tables`, kustotest.V1(kustotest.Table{
+		Columns: table.Columns{{Name: "TableName", Type: types.String}},
+		Rows:    [][]interface{}{{"A"}, {"B"}},
+	}))
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), ".show tables")
+	if err != nil {
+		t.Fatalf("QueryContext() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatalf("Scan() returned error: %v", err)
+		}
+		got = append(got, s)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if strings.Join(got, ",") != "A,B" {
+		t.Errorf("rows = %v, want A,B", got)
+	}
+}
@@ -0,0 +1,236 @@
+package kustosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/client"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/frames"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+var (
+	_ driver.Rows                           = (*rows)(nil)
+	_ driver.RowsNextResultSet              = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+)
+
+// scanTypes holds the Go type the values of each column type are scanned as.
+var scanTypes = map[types.Column]reflect.Type{
+	types.Bool:     reflect.TypeOf(false),
+	types.DateTime: reflect.TypeOf(time.Time{}),
+	types.Dynamic:  reflect.TypeOf([]byte{}),
+	types.GUID:     reflect.TypeOf(""),
+	types.Int:      reflect.TypeOf(int64(0)),
+	types.Long:     reflect.TypeOf(int64(0)),
+	types.Real:     reflect.TypeOf(float64(0)),
+	types.String:   reflect.TypeOf(""),
+	types.Timespan: reflect.TypeOf(time.Duration(0)),
+	types.Decimal:  reflect.TypeOf(""),
+}
+
+// rowSource yields the rows of a response, as the frames.RowIterator of queries does.
+type rowSource interface {
+	Next() bool
+	Row() *table.Row
+	Table() *table.Table
+	Err() error
+	Close() error
+}
+
+// emptyTable is the result set of responses without primary result tables.
+var emptyTable = &table.Table{Kind: table.KindPrimaryResult}
+
+// rows iterates over the rows of the primary result tables of a response, each table being a result set.
+// The rows are read from src as they are scanned, one row ahead of the caller, so that the end of a result
+// set is known when its last row is returned.
+type rows struct {
+	src rowSource
+
+	// tables holds the primary result tables started so far, and table the index of the current one.
+	tables []*table.Table
+	table  int
+
+	// next is the next primary result row of src, or nil at the end of src, and nextTable its table.
+	next      *table.Row
+	nextTable *table.Table
+	// err is the error that stopped src.
+	err error
+}
+
+// newRows runs the query csl and returns rows iterating over the rows of its response. The response is read
+// up to the first primary result row, so that the columns of the first result set are known.
+func newRows(ctx context.Context, c *client.Client, csl string, options []query.QueryOption) (*rows, error) {
+	r := &rows{}
+	options = append(options, query.IteratorOptions(frames.WithTableStart(r.startTable)))
+
+	it, err := c.QueryIter(ctx, "", csl, options...)
+	if err != nil {
+		return nil, err
+	}
+	r.src = it
+
+	r.fetch()
+	if r.next == nil && r.err != nil {
+		it.Close()
+		return nil, r.err
+	}
+	return r, nil
+}
+
+// newTableRows returns rows iterating over the rows of tables, which are held in memory.
+func newTableRows(tables []*table.Table) *rows {
+	r := &rows{src: &tableIterator{tables: tables, row: -1}, tables: tables}
+	r.fetch()
+	return r
+}
+
+// startTable records t as a result set when it is a primary result table.
+func (r *rows) startTable(t *table.Table) {
+	if t.Kind == table.KindPrimaryResult {
+		r.tables = append(r.tables, t)
+	}
+}
+
+// fetch reads the next primary result row of src, skipping the rows of the other tables.
+func (r *rows) fetch() {
+	for r.src.Next() {
+		if t := r.src.Table(); t.Kind == table.KindPrimaryResult {
+			r.next, r.nextTable = r.src.Row(), t
+			return
+		}
+	}
+	r.next, r.nextTable = nil, nil
+	r.err = r.src.Err()
+}
+
+func (r *rows) current() *table.Table {
+	if r.table < len(r.tables) {
+		return r.tables[r.table]
+	}
+	return emptyTable
+}
+
+// Columns implements driver.Rows.
+func (r *rows) Columns() []string {
+	return r.current().Columns.Names()
+}
+
+// Close implements driver.Rows. It releases the response, which may not be fully read.
+func (r *rows) Close() error {
+	return r.src.Close()
+}
+
+// Next implements driver.Rows.
+func (r *rows) Next(dest []driver.Value) error {
+	t := r.current()
+	if r.next == nil || r.nextTable != t {
+		if r.next == nil && r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	row := r.next
+	r.fetch()
+
+	for i, v := range row.Values {
+		if i >= len(dest) {
+			break
+		}
+		dv, err := driverValue(v)
+		if err != nil {
+			return errors.ErrWrapf(err, "column %q", t.Columns[i].Name)
+		}
+		dest[i] = dv
+	}
+	return nil
+}
+
+// HasNextResultSet implements driver.RowsNextResultSet. The rows of the current result set that were not
+// read are skipped, to read the response up to the next one.
+func (r *rows) HasNextResultSet() bool {
+	for r.next != nil && r.nextTable == r.current() {
+		r.fetch()
+	}
+	return r.table < len(r.tables)-1
+}
+
+// NextResultSet implements driver.RowsNextResultSet.
+func (r *rows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.table++
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName. It returns the Kusto type of
+// the column, upper cased, such as LONG or DATETIME.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return strings.ToUpper(string(r.current().Columns[index].Type))
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := scanTypes[r.current().Columns[index].Type]; ok {
+		return t
+	}
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable. All Kusto columns are nullable.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return true, true
+}
+
+// driverValue returns the value v is scanned as, which is also the value it is passed to database/sql as:
+// its native Go value, with ints widened to int64 and guids as strings, or nil when v is null.
+func driverValue(v value.Value) (driver.Value, error) {
+	return value.SQL(v).Value()
+}
+
+// tableIterator is a rowSource over tables held in memory, such as the tables of a management command.
+type tableIterator struct {
+	tables []*table.Table
+	// table is the index of the current table in tables, and row the index of the current row of the table.
+	table int
+	row   int
+}
+
+func (it *tableIterator) Next() bool {
+	it.row++
+	for it.table < len(it.tables) {
+		if it.row < len(it.tables[it.table].Rows) {
+			return true
+		}
+		it.table++
+		it.row = 0
+	}
+	return false
+}
+
+func (it *tableIterator) Row() *table.Row {
+	return it.tables[it.table].Rows[it.row]
+}
+
+func (it *tableIterator) Table() *table.Table {
+	return it.tables[it.table]
+}
+
+func (it *tableIterator) Err() error {
+	return nil
+}
+
+func (it *tableIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package kustotest provides an in-process fake Kusto server, to test code built on client.Client without
+// a cluster.
+//
+// The server answers metadata requests, and answers the queries and management commands it receives with
+// the canned responses registered with Handle:
+//
+//	srv := kustotest.New(t)
+//	srv.Handle("db", `^MyTable`, kustotest.V2(kustotest.Table{
+//		Columns: table.Columns{{Name: "Name", Type: types.String}},
+//		Rows:    [][]interface{}{{"a"}, {"b"}},
+//	}))
+//
+//	c, err := client.New(srv.ClientOptions()...)
+//	...
+//	ds, err := c.Query(ctx, "db", "MyTable | take 2")
+//
+// Recorder records the requests sent to a real cluster along with their responses, and replays them
+// without a cluster or credentials.
+package kustotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/crodriguezde/go-kusto/pkg/client"
+	"github.com/crodriguezde/go-kusto/pkg/conn"
+	"github.com/crodriguezde/go-kusto/pkg/internal/cloudcache"
+)
+
+// Paths the server answers.
+const (
+	MetadataPath = "/v1/rest/auth/metadata"
+	QueryPath    = "/v2/rest/query"
+	MgmtPath     = "/v1/rest/mgmt"
+)
+
+// Request is a query or management command received by the server.
+type Request struct {
+	// Path is QueryPath or MgmtPath.
+	Path string
+	// Header holds the headers of the request.
+	Header http.Header
+	// DB and CSL are the database and the statement of the request.
+	DB  string
+	CSL string
+	// Options and Parameters are the request properties.
+	Options    map[string]interface{}
+	Parameters map[string]string
+}
+
+// route is a canned response registered with Handle.
+type route struct {
+	db      string
+	pattern *regexp.Regexp
+	resp    Response
+	served  int
+}
+
+// Server is a fake Kusto server listening on a local TLS endpoint.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   []*route
+	requests []Request
+	metadata []byte
+}
+
+// New starts a Server, closed when the test ends.
+func New(tb testing.TB) *Server {
+	tb.Helper()
+
+	s := &Server{}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	s.SetCloudInfo(conn.DefaultCloudInfo)
+	tb.Cleanup(s.Close)
+
+	return s
+}
+
+// Token is the bearer token the clients of ClientOptions authenticate with. The server accepts any token.
+const Token = "kustotest"
+
+// ClientOptions returns the options of a client.Client sending its requests to the server, authenticated
+// with Token.
+func (s *Server) ClientOptions() []client.ClientOption {
+	return []client.ClientOption{
+		client.WithEndpoint(s.URL),
+		client.WithHttp(s.Client()),
+		client.WithTokenCredential(client.NewStaticTokenCredential(Token)),
+	}
+}
+
+// SetCloudInfo sets the cloud information served by the metadata endpoint. It also removes the cloud
+// information of the server from the process-wide cache of conn.Conn.CloudInfo, so that it is queried again
+// by the clients created afterwards. Existing clients keep the cloud information they already queried.
+func (s *Server) SetCloudInfo(ci conn.CloudInfo) {
+	b, _ := json.Marshal(conn.Metadata{AzureAD: ci})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = b
+	cloudcache.Forget(s.Listener.Addr().String())
+}
+
+// Handle registers resp as the response of the requests against db whose statement matches the regular
+// expression pattern. An empty db matches all the databases. Requests are answered by the first registered
+// response matching them that was not served as many Times as it allows. Handle panics if pattern is not
+// a valid regular expression.
+func (s *Server) Handle(db string, pattern string, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes = append(s.routes, &route{db: db, pattern: regexp.MustCompile(pattern), resp: resp})
+}
+
+// Requests returns the queries and management commands received by the server, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// Reset forgets the registered responses and the received requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes = nil
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == MetadataPath:
+		s.mu.Lock()
+		b := s.metadata
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	case r.Method == http.MethodPost && (r.URL.Path == QueryPath || r.URL.Path == MgmtPath):
+		s.serveRequest(w, r)
+	default:
+		Error(http.StatusNotFound, "NotFound", fmt.Sprintf("%s %s is not served", r.Method, r.URL.Path), true).write(w)
+	}
+}
+
+// serveRequest records a query or management command, and answers it with its registered response.
+func (s *Server) serveRequest(w http.ResponseWriter, r *http.Request) {
+	var msg struct {
+		DB         string `json:"db"`
+		CSL        string `json:"csl"`
+		Properties struct {
+			Options    map[string]interface{}
+			Parameters map[string]string
+		} `json:"properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		Error(http.StatusBadRequest, "BadRequest_InvalidBody", err.Error(), true).write(w)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Path:       r.URL.Path,
+		Header:     r.Header.Clone(),
+		DB:         msg.DB,
+		CSL:        msg.CSL,
+		Options:    msg.Properties.Options,
+		Parameters: msg.Properties.Parameters,
+	})
+	resp, ok := s.match(msg.DB, msg.CSL)
+	s.mu.Unlock()
+
+	if !ok {
+		Error(http.StatusBadRequest, "BadRequest_NoCannedResponse", fmt.Sprintf("no response registered for %q in database %q", msg.CSL, msg.DB), true).write(w)
+		return
+	}
+	resp.write(w)
+}
+
+// match returns the response of the first route matching db and csl, and counts it as served.
+// s.mu must be held.
+func (s *Server) match(db, csl string) (Response, bool) {
+	for _, rt := range s.routes {
+		if rt.db != "" && rt.db != db {
+			continue
+		}
+		if !rt.pattern.MatchString(csl) {
+			continue
+		}
+		if rt.resp.times > 0 && rt.served >= rt.resp.times {
+			continue
+		}
+		rt.served++
+		return rt.resp, true
+	}
+	return Response{}, false
+}
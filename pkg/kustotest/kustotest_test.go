@@ -0,0 +1,196 @@
+package kustotest_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/crodriguezde/go-kusto/pkg/client"
+	"github.com/crodriguezde/go-kusto/pkg/conn"
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/frames"
+	"github.com/crodriguezde/go-kusto/pkg/kustotest"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+)
+
+var names = kustotest.Table{
+	Columns: table.Columns{{Name: "Name", Type: types.String}, {Name: "Count", Type: types.Long}},
+	Rows:    [][]interface{}{{"a", 1}, {"b", 2}},
+}
+
+func newClient(t *testing.T, srv *kustotest.Server, options ...client.ClientOption) *client.Client {
+	t.Helper()
+
+	c, err := client.New(append(srv.ClientOptions(), options...)...)
+	if err != nil {
+		t.Fatalf("client.New() returned error: %v", err)
+	}
+	return c
+}
+
+func TestQuery(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^Names`, kustotest.V2(names))
+	c := newClient(t, srv)
+
+	ds, err := c.Query(context.Background(), "db", "Names | take 2", query.ClientRequestID("id"), query.NoTruncation())
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	rows := ds.PrimaryResults()[0].Rows
+	if len(rows) != 2 || rows[1].Values[0].String() != "b" || rows[1].Values[1].String() != "2" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if r.Path != kustotest.QueryPath || r.DB != "db" || r.CSL != "Names | take 2" {
+		t.Errorf("unexpected request: %+v", r)
+	}
+	if r.Header.Get(conn.ClientRequestIdHeader) != "id" || r.Header.Get("Authorization") != "Bearer "+kustotest.Token {
+		t.Errorf("unexpected headers: %v", r.Header)
+	}
+	if r.Options[query.NoTruncationValue] != true {
+		t.Errorf("unexpected options: %v", r.Options)
+	}
+}
+
+func TestQueryGzip(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("", `.*`, kustotest.V2(names).Gzip())
+	c := newClient(t, srv)
+
+	ds, err := c.Query(context.Background(), "any", "Names")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if len(ds.PrimaryResults()[0].Rows) != 2 {
+		t.Errorf("unexpected dataset: %+v", ds.Tables)
+	}
+}
+
+func TestQueryProgressive(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `Names`, kustotest.Progressive(names))
+	c := newClient(t, srv)
+
+	var progress []float64
+	it, err := c.QueryIter(context.Background(), "db", "Names", query.IteratorOptions(frames.WithProgress(func(p frames.Progress) {
+		progress = append(progress, p.Percentage)
+	})))
+	if err != nil {
+		t.Fatalf("QueryIter() returned error: %v", err)
+	}
+	defer it.Close()
+
+	ds, err := it.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned error: %v", err)
+	}
+	if len(ds.PrimaryResults()[0].Rows) != 2 || len(progress) != 2 || progress[1] != 100 {
+		t.Errorf("got %d rows and progress %v", len(ds.PrimaryResults()[0].Rows), progress)
+	}
+}
+
+func TestQueryPartialFailure(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `Names`, kustotest.PartialFailure("LimitsExceeded", "Query result set has exceeded the internal record count limit", names))
+	c := newClient(t, srv)
+
+	ds, err := c.Query(context.Background(), "db", "Names")
+	var partial *errors.PartialFailureError
+	if !stderrors.As(err, &partial) || partial.Errors[0].Code != "LimitsExceeded" {
+		t.Fatalf("Query() returned %v, want a partial failure", err)
+	}
+	if ds == nil || len(ds.PrimaryResults()[0].Rows) != 2 {
+		t.Errorf("unexpected dataset: %+v", ds)
+	}
+}
+
+func TestQueryThrottled(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `Names`, kustotest.Throttled(0).Times(2))
+	srv.Handle("db", `Names`, kustotest.V2(names))
+	c := newClient(t, srv, client.WithRetryOptions(conn.RetryOptions{MaxRetries: 3, RetryDelay: time.Millisecond}))
+
+	if _, err := c.Query(context.Background(), "db", "Names"); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+}
+
+func TestMgmt(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^\.show tables`, kustotest.V1(kustotest.Table{
+		Columns: table.Columns{{Name: "TableName", Type: types.String}},
+		Rows:    [][]interface{}{{"T1"}, {"T2"}},
+	}))
+	c := newClient(t, srv)
+
+	ds, err := c.Mgmt(context.Background(), "db", ".show tables")
+	if err != nil {
+		t.Fatalf("Mgmt() returned error: %v", err)
+	}
+	if rows := ds.Tables[0].Rows; len(rows) != 2 || rows[0].Values[0].String() != "T1" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if r := srv.Requests()[0]; r.Path != kustotest.MgmtPath {
+		t.Errorf("request sent to %s", r.Path)
+	}
+}
+
+func TestNoCannedResponse(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `Names`, kustotest.V2(names))
+	c := newClient(t, srv)
+
+	_, err := c.Query(context.Background(), "other", "Names")
+	var kerr *errors.KustoError
+	if !stderrors.As(err, &kerr) || kerr.StatusCode != http.StatusBadRequest || !kerr.Permanent {
+		t.Errorf("Query() returned %v, want a permanent bad request", err)
+	}
+}
+
+func TestSetCloudInfo(t *testing.T) {
+	srv := kustotest.New(t)
+	srv.Handle("db", `^Names`, kustotest.V2(names))
+
+	// scope returns the scope of the tokens requested by a new client sending a query.
+	scope := func() string {
+		var scopes []string
+		c := newClient(t, srv, client.WithTokenCredential(client.FuncCredential(
+			func(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+				scopes = options.Scopes
+				return azcore.AccessToken{Token: kustotest.Token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+			})))
+		if _, err := c.Query(context.Background(), "db", "Names"); err != nil {
+			t.Fatalf("Query() returned error: %v", err)
+		}
+		if len(scopes) != 1 {
+			t.Fatalf("token requested with scopes %v, want one scope", scopes)
+		}
+		return scopes[0]
+	}
+
+	if got, want := scope(), conn.DefaultCloudInfo.KustoServiceResourceID+"/.default"; got != want {
+		t.Errorf("scope = %q, want %q", got, want)
+	}
+
+	ci := conn.DefaultCloudInfo
+	ci.KustoServiceResourceID = "https://kusto.sovereign.cloud"
+	srv.SetCloudInfo(ci)
+	if got, want := scope(), "https://kusto.sovereign.cloud/.default"; got != want {
+		t.Errorf("scope after SetCloudInfo() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,298 @@
+package kustotest
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// ModeRecord sends the requests to the cluster and records them along with their responses.
+	ModeRecord Mode = iota
+	// ModeReplay answers the requests with the recorded responses, without sending them.
+	ModeReplay
+)
+
+// scrubbedHeaders are the request headers that are not recorded, as they hold credentials.
+var scrubbedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Interaction is a request recorded along with its response.
+type Interaction struct {
+	Request  RecordedRequest
+	Response RecordedResponse
+}
+
+// RecordedRequest is a recorded request. DB, CSL, Options and Parameters are only set for queries and
+// management commands.
+type RecordedRequest struct {
+	Method     string
+	Path       string
+	Header     http.Header
+	DB         string                 `json:",omitempty"`
+	CSL        string                 `json:",omitempty"`
+	Options    map[string]interface{} `json:",omitempty"`
+	Parameters map[string]string      `json:",omitempty"`
+}
+
+// RecordedResponse is a recorded response. Body is recorded decompressed.
+type RecordedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// Recorder is an http.RoundTripper recording requests and their responses to a file, and replaying them.
+// Requests are matched on their method and path, and for queries and management commands, on their database,
+// statement, query parameter values and the request properties given to WithMatchedProperties. Credentials
+// are scrubbed from the recorded requests.
+//
+// It is used as the transport of a client.Client with client.WithHttp(recorder.Client()).
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+	matched   []string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(r *Recorder)
+
+// WithTransport sets the transport sending the requests in ModeRecord. It defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithMatchedProperties sets the request properties, such as query.ServerTimeoutValue, that must be equal
+// for a recorded request to match a request.
+func WithMatchedProperties(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.matched = append(r.matched, names...)
+	}
+}
+
+// NewRecorder returns a Recorder in the given mode, recording to or replaying from the file at path.
+func NewRecorder(path string, mode Mode, options ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: http.DefaultTransport,
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	if mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+		if err := json.Unmarshal(b, &r.interactions); err != nil {
+			return nil, fmt.Errorf("failed to decode recording %s: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.interactions))
+	}
+
+	return r, nil
+}
+
+// Client returns an http.Client using the Recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{
+		Transport: r,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Save writes the recorded interactions to the file of the Recorder. It does nothing in ModeReplay.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	if err := os.WriteFile(r.path, b, 0o600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rr, body, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, rr)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded, err := recordResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: rr, Response: recorded})
+	r.mu.Unlock()
+
+	return recorded.response(req), nil
+}
+
+// replay returns the response of the first recorded request matching rr that was not replayed yet, or of
+// the last one matching it when all were replayed.
+func (r *Recorder) replay(req *http.Request, rr RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, in := range r.interactions {
+		if !r.match(in.Request, rr) {
+			continue
+		}
+		if !r.replayed[i] {
+			r.replayed[i] = true
+			return in.Response.response(req), nil
+		}
+		last = i
+	}
+	if last >= 0 {
+		return r.interactions[last].Response.response(req), nil
+	}
+
+	return nil, fmt.Errorf("kustotest: no recorded response for %s %s (db %q, csl %q)", rr.Method, rr.Path, rr.DB, rr.CSL)
+}
+
+// match reports whether the recorded request matches rr.
+func (r *Recorder) match(recorded, rr RecordedRequest) bool {
+	if recorded.Method != rr.Method || recorded.Path != rr.Path || recorded.DB != rr.DB || recorded.CSL != rr.CSL {
+		return false
+	}
+	// Requests without parameters may send an empty map or none.
+	if (len(recorded.Parameters) > 0 || len(rr.Parameters) > 0) && !reflect.DeepEqual(recorded.Parameters, rr.Parameters) {
+		return false
+	}
+	for _, name := range r.matched {
+		if !reflect.DeepEqual(recorded.Options[name], rr.Options[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordRequest returns the recorded form of req, and its body, which is read.
+func recordRequest(req *http.Request) (RecordedRequest, []byte, error) {
+	rr := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Header: req.Header.Clone(),
+	}
+	for _, name := range scrubbedHeaders {
+		rr.Header.Del(name)
+	}
+
+	if req.Body == nil {
+		return rr, nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return rr, nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	var msg struct {
+		DB         string `json:"db"`
+		CSL        string `json:"csl"`
+		Properties struct {
+			Options    map[string]interface{}
+			Parameters map[string]string
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(body, &msg); err == nil {
+		rr.DB = msg.DB
+		rr.CSL = msg.CSL
+		rr.Options = msg.Properties.Options
+		rr.Parameters = msg.Properties.Parameters
+	}
+
+	return rr, body, nil
+}
+
+// recordResponse reads resp and returns its recorded form, with its body decompressed.
+func recordResponse(resp *http.Response) (RecordedResponse, error) {
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	switch resp.Header.Get("Content-Encoding") {
+	case "gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return RecordedResponse{}, fmt.Errorf("failed to decompress response: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	case "deflate":
+		fr := flate.NewReader(resp.Body)
+		defer fr.Close()
+		body = fr
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return RecordedResponse{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+
+	return RecordedResponse{StatusCode: resp.StatusCode, Header: header, Body: string(b)}, nil
+}
+
+// response returns the recorded response as the response of req.
+func (rr RecordedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rr.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(rr.Body))),
+		ContentLength: int64(len(rr.Body)),
+		Request:       req,
+	}
+}
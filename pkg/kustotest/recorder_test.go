@@ -0,0 +1,167 @@
+package kustotest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crodriguezde/go-kusto/pkg/client"
+	"github.com/crodriguezde/go-kusto/pkg/kustotest"
+	"github.com/crodriguezde/go-kusto/pkg/query"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	one := kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.Long}}, Rows: [][]interface{}{{1}}}
+	two := kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.Long}}, Rows: [][]interface{}{{1}, {2}}}
+
+	srv := kustotest.New(t)
+	srv.Handle("db", `^T$`, kustotest.V2(one).Times(1).Gzip())
+	srv.Handle("db", `^T$`, kustotest.V2(two))
+
+	rec, err := kustotest.NewRecorder(path, kustotest.ModeRecord,
+		kustotest.WithTransport(srv.Client().Transport),
+		kustotest.WithMatchedProperties(query.QueryTakeMaxRecordsValue),
+	)
+	if err != nil {
+		t.Fatalf("NewRecorder() returned error: %v", err)
+	}
+
+	run := func(c *client.Client) []int {
+		t.Helper()
+
+		var counts []int
+		for _, max := range []int64{1, 2} {
+			ds, err := c.Query(context.Background(), "db", "T", query.QueryTakeMaxRecords(max))
+			if err != nil {
+				t.Fatalf("Query() returned error: %v", err)
+			}
+			counts = append(counts, len(ds.PrimaryResults()[0].Rows))
+		}
+		return counts
+	}
+
+	c, err := client.New(
+		client.WithEndpoint(srv.URL),
+		client.WithHttp(rec.Client()),
+		client.WithTokenCredential(client.NewStaticTokenCredential(kustotest.Token)),
+	)
+	if err != nil {
+		t.Fatalf("client.New() returned error: %v", err)
+	}
+	recorded := run(c)
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read recording: %v", err)
+	}
+	if strings.Contains(string(b), "Bearer") || strings.Contains(string(b), "Authorization") {
+		t.Errorf("the recording holds credentials")
+	}
+
+	// Replay without the server, and with the queries in the opposite order: the recordings are matched on
+	// the take_max_records property.
+	srv.Close()
+	rep, err := kustotest.NewRecorder(path, kustotest.ModeReplay, kustotest.WithMatchedProperties(query.QueryTakeMaxRecordsValue))
+	if err != nil {
+		t.Fatalf("NewRecorder() returned error: %v", err)
+	}
+	c, err = client.New(
+		client.WithEndpoint(srv.URL),
+		client.WithHttp(rep.Client()),
+		client.WithTokenCredential(client.NewStaticTokenCredential(kustotest.Token)),
+	)
+	if err != nil {
+		t.Fatalf("client.New() returned error: %v", err)
+	}
+
+	for i, max := range []int64{2, 1} {
+		ds, err := c.Query(context.Background(), "db", "T", query.QueryTakeMaxRecords(max))
+		if err != nil {
+			t.Fatalf("Query() returned error: %v", err)
+		}
+		if got, want := len(ds.PrimaryResults()[0].Rows), recorded[1-i]; got != want {
+			t.Errorf("replayed query with take_max_records %d returned %d rows, want %d", max, got, want)
+		}
+	}
+
+	if _, err := c.Query(context.Background(), "db", "Unknown"); err == nil {
+		t.Errorf("Query() of an unrecorded query returned no error")
+	}
+}
+
+func TestRecorderParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	srv := kustotest.New(t)
+	srv.Handle("db", `T`, kustotest.V2(kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.String}}, Rows: [][]interface{}{{"a"}}}).Times(1))
+	srv.Handle("db", `T`, kustotest.V2(kustotest.Table{Columns: table.Columns{{Name: "x", Type: types.String}}, Rows: [][]interface{}{{"b"}}}))
+
+	params, err := query.NewParameters(query.ParamTypes{"name": {Type: types.String}})
+	if err != nil {
+		t.Fatalf("NewParameters() returned error: %v", err)
+	}
+	// run runs the same statement with name as the value of its parameter, and returns the value of its row.
+	run := func(c *client.Client, name string) (string, error) {
+		p, err := params.With(map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatalf("With() returned error: %v", err)
+		}
+		ds, err := c.Query(context.Background(), "db", "T | where Name == name", query.QueryParameters(p))
+		if err != nil {
+			return "", err
+		}
+		return ds.PrimaryResults()[0].Rows[0].Values[0].String(), nil
+	}
+
+	rec, err := kustotest.NewRecorder(path, kustotest.ModeRecord, kustotest.WithTransport(srv.Client().Transport))
+	if err != nil {
+		t.Fatalf("NewRecorder() returned error: %v", err)
+	}
+	c, err := client.New(client.WithEndpoint(srv.URL), client.WithHttp(rec.Client()),
+		client.WithTokenCredential(client.NewStaticTokenCredential(kustotest.Token)))
+	if err != nil {
+		t.Fatalf("client.New() returned error: %v", err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if _, err := run(c, name); err != nil {
+			t.Fatalf("Query() returned error: %v", err)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	srv.Close()
+	rep, err := kustotest.NewRecorder(path, kustotest.ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder() returned error: %v", err)
+	}
+	c, err = client.New(client.WithEndpoint(srv.URL), client.WithHttp(rep.Client()),
+		client.WithTokenCredential(client.NewStaticTokenCredential(kustotest.Token)))
+	if err != nil {
+		t.Fatalf("client.New() returned error: %v", err)
+	}
+
+	// The recordings are matched on the parameter values, whatever the order of the queries.
+	for _, name := range []string{"b", "a", "b"} {
+		got, err := run(c, name)
+		if err != nil {
+			t.Fatalf("replayed Query() with name %q returned error: %v", name, err)
+		}
+		if got != name {
+			t.Errorf("replayed Query() with name %q returned %q", name, got)
+		}
+	}
+	if _, err := run(c, "c"); err == nil {
+		t.Errorf("Query() with an unrecorded parameter value returned no error")
+	}
+}
@@ -0,0 +1,223 @@
+package kustotest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/frames"
+	"github.com/crodriguezde/go-kusto/pkg/table"
+)
+
+// Table is a table of a canned response.
+type Table struct {
+	// Name and Kind default to PrimaryResult.
+	Name string
+	Kind string
+	// Columns are the columns of the table.
+	Columns table.Columns
+	// Rows hold the values of the rows, as they are encoded in JSON: numbers, strings, booleans, objects
+	// or nil.
+	Rows [][]interface{}
+}
+
+func (t Table) name() string {
+	if t.Name == "" {
+		return table.KindPrimaryResult
+	}
+	return t.Name
+}
+
+func (t Table) kind() string {
+	if t.Kind == "" {
+		return table.KindPrimaryResult
+	}
+	return t.Kind
+}
+
+// Response is a canned response of the server.
+type Response struct {
+	status int
+	header http.Header
+	body   []byte
+	gzip   bool
+	times  int
+}
+
+// Gzip returns the response, compressed with gzip.
+func (r Response) Gzip() Response {
+	r.gzip = true
+	return r
+}
+
+// Times returns the response, served at most n times. Requests that would match it once it was served n
+// times are answered by the following matching responses.
+func (r Response) Times(n int) Response {
+	r.times = n
+	return r
+}
+
+// WithHeader returns the response, sent with the header name set to v.
+func (r Response) WithHeader(name, v string) Response {
+	h := r.header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set(name, v)
+	r.header = h
+	return r
+}
+
+func (r Response) write(w http.ResponseWriter) {
+	for name, values := range r.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	body := r.body
+	if r.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		zw.Close()
+		body = buf.Bytes()
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	w.WriteHeader(r.status)
+	w.Write(body)
+}
+
+// V2 returns a successful query response holding tables.
+func V2(tables ...Table) Response {
+	return v2(false, tables, nil)
+}
+
+// Progressive returns a successful progressive query response holding tables. The rows of each table are
+// sent in a TableFragment frame each, followed by a TableProgress frame.
+func Progressive(tables ...Table) Response {
+	return v2(true, tables, nil)
+}
+
+// PartialFailure returns a query response holding tables, whose DataSetCompletion frame reports an error
+// with the given code and message.
+func PartialFailure(code, message string, tables ...Table) Response {
+	return v2(false, tables, []json.RawMessage{oneAPIError(code, message, true)})
+}
+
+// V1 returns a successful management command response holding tables.
+func V1(tables ...Table) Response {
+	resp := frames.V1Response{Tables: []frames.V1Table{}}
+	for _, t := range tables {
+		vt := frames.V1Table{TableName: t.name(), Rows: []json.RawMessage{}}
+		for _, c := range t.Columns {
+			vt.Columns = append(vt.Columns, frames.V1Column{ColumnName: c.Name, ColumnType: string(c.Type)})
+		}
+		for _, row := range t.Rows {
+			vt.Rows = append(vt.Rows, mustMarshal(row))
+		}
+		resp.Tables = append(resp.Tables, vt)
+	}
+	return Response{status: http.StatusOK, body: mustMarshal(resp)}
+}
+
+// Error returns a failed response with the given status, and a OneApi error payload with code and message.
+func Error(status int, code, message string, permanent bool) Response {
+	return Response{status: status, body: oneAPIError(code, message, permanent)}
+}
+
+// Throttled returns a response rejecting the request because of throttling, asking to retry after
+// retryAfter.
+func Throttled(retryAfter time.Duration) Response {
+	return Error(http.StatusTooManyRequests, "TooManyRequests", "the request was throttled", false).
+		WithHeader("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+}
+
+// Raw returns a response with the given status and body.
+func Raw(status int, body string) Response {
+	return Response{status: status, body: []byte(body)}
+}
+
+// v2 returns a v2 query response holding tables, progressive or not, whose DataSetCompletion frame
+// reports errs.
+func v2(progressive bool, tables []Table, errs []json.RawMessage) Response {
+	fs := []interface{}{
+		frames.DataSetHeader{FrameType: frames.TypeDataSetHeader, IsProgressive: progressive, Version: "v2.0"},
+	}
+
+	for i, t := range tables {
+		rows := t.Rows
+		if rows == nil {
+			rows = [][]interface{}{}
+		}
+
+		if !progressive {
+			fs = append(fs, frames.DataTable{
+				FrameType: frames.TypeDataTable,
+				TableID:   i,
+				TableKind: t.kind(),
+				TableName: t.name(),
+				Columns:   t.Columns,
+				Rows:      rows,
+			})
+			continue
+		}
+
+		fs = append(fs, frames.TableHeader{
+			FrameType: frames.TypeTableHeader,
+			TableID:   i,
+			TableKind: t.kind(),
+			TableName: t.name(),
+			Columns:   t.Columns,
+		})
+		for j, row := range rows {
+			fs = append(fs,
+				frames.TableFragment{
+					FrameType:         frames.TypeTableFragment,
+					TableID:           i,
+					FieldCount:        len(t.Columns),
+					TableFragmentType: frames.FragmentDataAppend,
+					Rows:              [][]interface{}{row},
+				},
+				frames.TableProgress{
+					FrameType:     frames.TypeTableProgress,
+					TableID:       i,
+					TableProgress: float64(j+1) * 100 / float64(len(rows)),
+				},
+			)
+		}
+		fs = append(fs, frames.TableCompletion{FrameType: frames.TypeTableCompletion, TableID: i, RowCount: len(rows)})
+	}
+
+	fs = append(fs, frames.DataSetCompletion{
+		FrameType:    frames.TypeDataSetCompletion,
+		HasErrors:    len(errs) > 0,
+		OneApiErrors: errs,
+	})
+
+	return Response{status: http.StatusOK, body: mustMarshal(fs)}
+}
+
+// oneAPIError returns a OneApi error payload.
+func oneAPIError(code, message string, permanent bool) json.RawMessage {
+	return mustMarshal(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":       code,
+			"message":    message,
+			"@message":   message,
+			"@permanent": permanent,
+		},
+	})
+}
+
+// mustMarshal marshals v, that only holds values that can be marshaled.
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,196 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+	"github.com/google/uuid"
+)
+
+// paramNameRE matches valid query parameter names.
+var paramNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// nativeTypes holds the Go type values of each column type are converted to when given as value.Value.
+var nativeTypes = map[types.Column]reflect.Type{
+	types.Bool:     reflect.TypeOf(false),
+	types.DateTime: reflect.TypeOf(time.Time{}),
+	types.Dynamic:  reflect.TypeOf([]byte{}),
+	types.GUID:     reflect.TypeOf(uuid.UUID{}),
+	types.Int:      reflect.TypeOf(int32(0)),
+	types.Long:     reflect.TypeOf(int64(0)),
+	types.Real:     reflect.TypeOf(float64(0)),
+	types.String:   reflect.TypeOf(""),
+	types.Timespan: reflect.TypeOf(time.Duration(0)),
+	types.Decimal:  reflect.TypeOf(""),
+}
+
+// Parameters holds the declaration of the query parameters of a query, and the values they take for a
+// single call. Values are sent to the service separately from the query text, so that user input is
+// never concatenated into KQL.
+//
+//	params, err := query.NewParameters(query.ParamTypes{
+//		"name":  {Type: types.String},
+//		"limit": {Type: types.Long, Default: int64(10)},
+//	})
+//	...
+//	params, err = params.With(map[string]interface{}{"name": userInput})
+//	...
+//	ds, err := conn.Query(ctx, db, "T | where Name == name | take limit", opts)
+type Parameters struct {
+	types  ParamTypes
+	values map[string]interface{}
+}
+
+// NewParameters returns Parameters declaring the given parameter types.
+func NewParameters(paramTypes ParamTypes) (*Parameters, error) {
+	declared := make(ParamTypes, len(paramTypes))
+	for name, p := range paramTypes {
+		if !paramNameRE.MatchString(name) {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "invalid query parameter name %q", name)
+		}
+		p.name = name
+		if err := p.validate(); err != nil {
+			return nil, errors.ErrWrapf(err, "query parameter %q", name)
+		}
+		declared[name] = p
+	}
+
+	return &Parameters{
+		types:  declared,
+		values: map[string]interface{}{},
+	}, nil
+}
+
+// With returns a copy of the Parameters holding the given values, in addition to the values already set.
+// Each value must be of the Go type matching the declared parameter type, as for ParamType.Default, or a
+// valid value.Value of that type. Values of dynamic parameters may also be a []byte or string holding JSON,
+// or any value that can be marshaled to JSON.
+func (p *Parameters) With(values map[string]interface{}) (*Parameters, error) {
+	n := &Parameters{
+		types:  p.types,
+		values: make(map[string]interface{}, len(p.values)+len(values)),
+	}
+	for name, v := range p.values {
+		n.values[name] = v
+	}
+
+	for name, v := range values {
+		pt, ok := p.types[name]
+		if !ok {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "query parameter %q was not declared", name)
+		}
+		nv, err := pt.normalize(v)
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "query parameter %q", name)
+		}
+		n.values[name] = nv
+	}
+
+	return n, nil
+}
+
+// Declaration returns the declare query_parameters statement declaring the parameters, or an empty string
+// if no parameters were declared.
+func (p *Parameters) Declaration() string {
+	if len(p.types) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(p.types))
+	for name := range p.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	decls := make([]string, 0, len(names))
+	for _, name := range names {
+		decls = append(decls, p.types[name].string())
+	}
+
+	return "declare query_parameters(" + strings.Join(decls, ", ") + ");\n"
+}
+
+// Statement returns csl preceded by the parameters declaration.
+func (p *Parameters) Statement(csl string) string {
+	return p.Declaration() + csl
+}
+
+// Values returns the values of the parameters rendered as KQL literals, as sent in the request properties.
+// Parameters without a value and without a default cause an error.
+func (p *Parameters) Values() (map[string]string, error) {
+	values := make(map[string]string, len(p.values))
+	for name, pt := range p.types {
+		v, ok := p.values[name]
+		if !ok {
+			if pt.Default == nil {
+				return nil, errors.ErrWrapf(errors.ErrInvalidType, "query parameter %q has no value and no default", name)
+			}
+			continue
+		}
+		values[name] = pt.literal(v)
+	}
+	return values, nil
+}
+
+// normalize validates v as a value of the parameter, returning it as the Go type literal expects.
+func (p ParamType) normalize(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "value cannot be nil")
+	}
+
+	if kv, ok := v.(value.Value); ok {
+		if reflect.ValueOf(kv).Kind() == reflect.Ptr && reflect.ValueOf(kv).IsNil() {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "value cannot be nil")
+		}
+		// Converting to a pointer leaves it nil when the value is not valid.
+		nv := reflect.New(reflect.PtrTo(nativeTypes[p.Type]))
+		if err := kv.Convert(nv.Elem()); err != nil {
+			return nil, err
+		}
+		if nv.Elem().IsNil() {
+			return nil, errors.ErrWrapf(errors.ErrInvalidType, "value %T is not valid", kv)
+		}
+		v = nv.Elem().Elem().Interface()
+	}
+
+	if p.Type == types.Dynamic {
+		return dynamicJSON(v)
+	}
+
+	check := p
+	check.Default = v
+	if err := check.validate(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// dynamicJSON returns the JSON of a dynamic parameter value.
+func dynamicJSON(v interface{}) ([]byte, error) {
+	var b []byte
+	switch d := v.(type) {
+	case []byte:
+		b = d
+	case string:
+		b = []byte(d)
+	default:
+		var err error
+		b, err = json.Marshal(d)
+		if err != nil {
+			return nil, errors.ErrWrapf(err, "dynamic value could not be marshaled")
+		}
+		return b, nil
+	}
+
+	if !json.Valid(b) {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "dynamic value is not valid JSON")
+	}
+	return b, nil
+}
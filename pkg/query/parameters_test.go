@@ -0,0 +1,189 @@
+package query
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+func TestParameters(t *testing.T) {
+	params, err := NewParameters(ParamTypes{
+		"name":  {Type: types.String},
+		"limit": {Type: types.Long, Default: int64(10)},
+		"since": {Type: types.DateTime},
+		"ratio": {Type: types.Real, Default: 0.5},
+		"props": {Type: types.Dynamic},
+	})
+	if err != nil {
+		t.Fatalf("NewParameters() returned error: %v", err)
+	}
+
+	params, err = params.With(map[string]interface{}{
+		"name":  `x" | drop`,
+		"since": time.Date(2023, 12, 21, 0, 0, 0, 0, time.UTC),
+		"props": map[string]int{"a": 1},
+	})
+	if err != nil {
+		t.Fatalf("With() returned error: %v", err)
+	}
+	params, err = params.With(map[string]interface{}{
+		"limit": &value.Long{Value: 5, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("With() returned error: %v", err)
+	}
+
+	wantDecl := "declare query_parameters(limit:long = long(10), name:string, props:dynamic, ratio:real = real(0.5), since:datetime);\n"
+	if got := params.Declaration(); got != wantDecl {
+		t.Errorf("Declaration() = %q, want %q", got, wantDecl)
+	}
+
+	values, err := params.Values()
+	if err != nil {
+		t.Fatalf("Values() returned error: %v", err)
+	}
+	wantValues := map[string]string{
+		"name":  `"x\" | drop"`,
+		"limit": "long(5)",
+		"since": "datetime(2023-12-21T00:00:00Z)",
+		"props": `dynamic({"a":1})`,
+	}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("Values() = %v, want %v", values, wantValues)
+	}
+
+	opts, err := NewQueryOptions(QueryParameters(params))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() returned error: %v", err)
+	}
+	if got := opts.Statement("T | take limit"); got != wantDecl+"T | take limit" {
+		t.Errorf("Statement() = %q", got)
+	}
+	if !reflect.DeepEqual(opts.RequestProperties.Parameters, wantValues) {
+		t.Errorf("RequestProperties.Parameters = %v, want %v", opts.RequestProperties.Parameters, wantValues)
+	}
+}
+
+func TestParametersErrors(t *testing.T) {
+	if _, err := NewParameters(ParamTypes{"bad name": {Type: types.String}}); err == nil {
+		t.Errorf("NewParameters() with invalid name succeeded, want error")
+	}
+	if _, err := NewParameters(ParamTypes{"a": {Type: types.Long, Default: 1}}); err == nil {
+		t.Errorf("NewParameters() with mismatched default succeeded, want error")
+	}
+
+	params, err := NewParameters(ParamTypes{"a": {Type: types.Long}})
+	if err != nil {
+		t.Fatalf("NewParameters() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+	}{
+		{name: "undeclared", values: map[string]interface{}{"b": int64(1)}},
+		{name: "wrong type", values: map[string]interface{}{"a": "1"}},
+		{name: "nil", values: map[string]interface{}{"a": nil}},
+		{name: "null value", values: map[string]interface{}{"a": &value.Long{}}},
+		{name: "wrong value type", values: map[string]interface{}{"a": &value.String{Value: "1", Valid: true}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := params.With(test.values); err == nil {
+				t.Errorf("With() succeeded, want error")
+			}
+		})
+	}
+
+	if _, err := params.Values(); err == nil {
+		t.Errorf("Values() without a value for a parameter without default succeeded, want error")
+	}
+}
+
+func TestParametersDecimal(t *testing.T) {
+	params, err := NewParameters(ParamTypes{"d": {Type: types.Decimal}})
+	if err != nil {
+		t.Fatalf("NewParameters() returned error: %v", err)
+	}
+
+	for _, v := range []interface{}{"-1.5", "1e5", "+0.25", ".5", &value.Decimal{Value: "-12345678901234567890.123456789", Valid: true}} {
+		p, err := params.With(map[string]interface{}{"d": v})
+		if err != nil {
+			t.Errorf("With(%v) returned error: %v", v, err)
+			continue
+		}
+		if _, err := p.Values(); err != nil {
+			t.Errorf("Values() with %v returned error: %v", v, err)
+		}
+	}
+
+	for _, v := range []interface{}{"1.2.3", "abc", "Inf", "", "0x10", "1_0", "0b101", new(big.Float).SetInf(false)} {
+		if _, err := params.With(map[string]interface{}{"d": v}); err == nil {
+			t.Errorf("With(%v) succeeded, want error", v)
+		}
+	}
+}
+
+func TestParametersRealSpecialValues(t *testing.T) {
+	params, err := NewParameters(ParamTypes{"r": {Type: types.Real}})
+	if err != nil {
+		t.Fatalf("NewParameters() returned error: %v", err)
+	}
+
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{v: math.NaN(), want: "real(nan)"},
+		{v: math.Inf(1), want: "real(+inf)"},
+		{v: math.Inf(-1), want: "real(-inf)"},
+		{v: -2.5, want: "real(-2.5)"},
+	}
+
+	for _, test := range tests {
+		p, err := params.With(map[string]interface{}{"r": test.v})
+		if err != nil {
+			t.Errorf("With(%v) returned error: %v", test.v, err)
+			continue
+		}
+		values, err := p.Values()
+		if err != nil {
+			t.Errorf("Values() with %v returned error: %v", test.v, err)
+			continue
+		}
+		if values["r"] != test.want {
+			t.Errorf("Values()[r] with %v = %q, want %q", test.v, values["r"], test.want)
+		}
+	}
+
+	withDefault, err := NewParameters(ParamTypes{"r": {Type: types.Real, Default: math.Inf(1)}})
+	if err != nil {
+		t.Fatalf("NewParameters() with an infinite default returned error: %v", err)
+	}
+	if want := "declare query_parameters(r:real = real(+inf));\n"; withDefault.Declaration() != want {
+		t.Errorf("Declaration() = %q, want %q", withDefault.Declaration(), want)
+	}
+}
+
+func TestParametersStringEscapes(t *testing.T) {
+	params, err := NewParameters(ParamTypes{"s": {Type: types.String}})
+	if err != nil {
+		t.Fatalf("NewParameters() returned error: %v", err)
+	}
+	p, err := params.With(map[string]interface{}{"s": "smile 😀 é ☃"})
+	if err != nil {
+		t.Fatalf("With() returned error: %v", err)
+	}
+	values, err := p.Values()
+	if err != nil {
+		t.Fatalf("Values() returned error: %v", err)
+	}
+	if want := `"smile \ud83d\ude00 é \u2603"`; values["s"] != want {
+		t.Errorf("Values()[s] = %s, want %s", values["s"], want)
+	}
+}
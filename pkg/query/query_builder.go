@@ -2,7 +2,9 @@ package query
 
 import (
 	"fmt"
+	"math"
 	"math/big"
+	"strconv"
 	"time"
 
 	"github.com/crodriguezde/go-kusto/pkg/errors"
@@ -75,7 +77,8 @@ func (p ParamType) validate() error {
 	case types.Decimal:
 		switch v := p.Default.(type) {
 		case string:
-			if !value.DecRE.MatchString(v) {
+			// Parse as Decimal.Unmarshal does, so that only base 10 numbers are written in the literal.
+			if _, err := value.ParseDecimal(v); err != nil {
 				return errors.ErrWrapf(errors.ErrInvalidType, "string representing decimal does not appear to be a decimal number, was %v", v)
 			}
 			return nil
@@ -83,6 +86,9 @@ func (p ParamType) validate() error {
 			if v == nil {
 				return errors.ErrWrapf(errors.ErrInvalidType, "*big.Float type cannot be set to the nil value")
 			}
+			if v.IsInf() {
+				return errors.ErrWrapf(errors.ErrInvalidType, "*big.Float type cannot be set to an infinite value")
+			}
 			return nil
 		case *big.Int:
 			if v == nil {
@@ -96,70 +102,53 @@ func (p ParamType) validate() error {
 }
 
 func (p ParamType) string() string {
+	if p.Default == nil || p.Type == types.Dynamic {
+		return fmt.Sprintf("%s:%s", p.name, p.Type)
+	}
+	return fmt.Sprintf("%s:%s = %s", p.name, p.Type, p.literal(p.Default))
+}
+
+// literal renders v, which must have passed validation for the type of p, as a KQL literal.
+func (p ParamType) literal(v interface{}) string {
 	switch p.Type {
 	case types.Bool:
-		if p.Default == nil {
-			return p.name + ":bool"
-		}
-		v := p.Default.(bool)
-		return fmt.Sprintf("%s:bool = bool(%v)", p.name, v)
+		return fmt.Sprintf("bool(%v)", v.(bool))
 	case types.DateTime:
-		if p.Default == nil {
-			return p.name + ":datetime"
-		}
-		v := p.Default.(time.Time)
-		return fmt.Sprintf("%s:datetime = datetime(%s)", p.name, v.Format(time.RFC3339Nano))
+		return fmt.Sprintf("datetime(%s)", v.(time.Time).UTC().Format(time.RFC3339Nano))
 	case types.Dynamic:
-		return p.name + ":dynamic"
+		return fmt.Sprintf("dynamic(%s)", v.([]byte))
 	case types.GUID:
-		if p.Default == nil {
-			return p.name + ":guid"
-		}
-		v := p.Default.(uuid.UUID)
-		return fmt.Sprintf("%s:guid = guid(%s)", p.name, v.String())
+		return fmt.Sprintf("guid(%s)", v.(uuid.UUID).String())
 	case types.Int:
-		if p.Default == nil {
-			return p.name + ":int"
-		}
-		v := p.Default.(int32)
-		return fmt.Sprintf("%s:int = int(%d)", p.name, v)
+		return fmt.Sprintf("int(%d)", v.(int32))
 	case types.Long:
-		if p.Default == nil {
-			return p.name + ":long"
-		}
-		v := p.Default.(int64)
-		return fmt.Sprintf("%s:long = long(%d)", p.name, v)
+		return fmt.Sprintf("long(%d)", v.(int64))
 	case types.Real:
-		if p.Default == nil {
-			return p.name + ":real"
-		}
-		v := p.Default.(float64)
-		return fmt.Sprintf("%s:real = real(%f)", p.name, v)
+		f := v.(float64)
+		switch {
+		case math.IsNaN(f):
+			return "real(nan)"
+		case math.IsInf(f, 1):
+			return "real(+inf)"
+		case math.IsInf(f, -1):
+			return "real(-inf)"
+		}
+		return fmt.Sprintf("real(%s)", strconv.FormatFloat(f, 'f', -1, 64))
 	case types.String:
-		if p.Default == nil {
-			return p.name + ":string"
-		}
-		v := p.Default.(string)
-		return fmt.Sprintf(`%s:string = %s`, p.name, utils.QuoteString(v, false))
+		return utils.QuoteString(v.(string), false)
 	case types.Timespan:
-		if p.Default == nil {
-			return p.name + ":timespan"
-		}
-		v := p.Default.(time.Duration)
-		return fmt.Sprintf("%s:timespan = timespan(%s)", p.name, value.Timespan{Value: v, Valid: true}.Marshal())
+		return fmt.Sprintf("timespan(%s)", value.Timespan{Value: v.(time.Duration), Valid: true}.Marshal())
 	case types.Decimal:
-		if p.Default == nil {
-			return p.name + ":decimal"
-		}
-
 		var sval string
-		switch v := p.Default.(type) {
+		switch d := v.(type) {
 		case string:
-			sval = v
+			sval = d
 		case *big.Float:
-			sval = v.String()
+			sval = d.Text('f', -1)
+		case *big.Int:
+			sval = d.String()
 		}
-		return fmt.Sprintf("%s:decimal = decimal(%s)", p.name, sval)
+		return fmt.Sprintf("decimal(%s)", sval)
 	}
-	panic("internal bug: ParamType.string() called without a call to .validate()")
+	panic("internal bug: ParamType.literal() called without a call to .validate()")
 }
@@ -1,8 +1,11 @@
 package query
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/frames"
 	"github.com/crodriguezde/go-kusto/pkg/value"
 )
 
@@ -58,6 +61,44 @@ const TruncationMaxRecordsValue = "truncation_max_records"
 const TruncationMaxSizeValue = "truncation_max_size"
 const ValidatePermissionsValue = "validate_permissions"
 
+// DataScope is the data scope of a query, set with QueryDatascope.
+type DataScope string
+
+// Data scopes of a query.
+const (
+	// DataScopeDefault lets the service choose the scope of the data, hot cache or all data.
+	DataScopeDefault DataScope = "default"
+	// DataScopeAll queries all the data, including the data that is not in the hot cache.
+	DataScopeAll DataScope = "all"
+	// DataScopeHotCache only queries the data in the hot cache.
+	DataScopeHotCache DataScope = "hotcache"
+)
+
+// Consistency is the consistency of a query, set with QueryConsistency.
+type Consistency string
+
+// Consistencies of a query.
+const (
+	// ConsistencyStrong queries the latest committed data.
+	ConsistencyStrong Consistency = "strongconsistency"
+	// ConsistencyWeak lets any node answer with possibly stale data.
+	ConsistencyWeak Consistency = "weakconsistency"
+	// ConsistencyAffinitizedWeak answers the queries of a user with the same node.
+	ConsistencyAffinitizedWeak Consistency = "affinitizedweakconsistency"
+	// ConsistencyDatabaseAffinitizedWeak answers the queries of a database with the same node.
+	ConsistencyDatabaseAffinitizedWeak Consistency = "databaseaffinitizedweakconsistency"
+)
+
+// Language is the language of a query, set with QueryLanguage.
+type Language string
+
+// Languages of a query.
+const (
+	LanguageKQL Language = "kql"
+	LanguageCSL Language = "csl"
+	LanguageSQL Language = "sql"
+)
+
 type RequestProperties struct {
 	Options         map[string]interface{}
 	Parameters      map[string]string
@@ -69,10 +110,57 @@ type RequestProperties struct {
 
 type QueryOptions struct {
 	RequestProperties *RequestProperties
+	// LenientPartialFailures keeps all the rows of a response reporting partial failures, instead of
+	// stopping at the first failure.
+	LenientPartialFailures bool
+	// IteratorOptions configure the iterator returned by QueryIter.
+	IteratorOptions []frames.IteratorOption
+	params          *Parameters
 }
 
 type QueryOption func(q *QueryOptions) error
 
+// NewQueryOptions returns QueryOptions with the given options applied.
+func NewQueryOptions(options ...QueryOption) (*QueryOptions, error) {
+	q := &QueryOptions{
+		RequestProperties: &RequestProperties{
+			Options:    map[string]interface{}{},
+			Parameters: map[string]string{},
+		},
+	}
+
+	for _, option := range options {
+		if err := option(q); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// Statement returns the statement to send for csl, preceded by the query parameters declaration when
+// QueryParameters was used.
+func (q *QueryOptions) Statement(csl string) string {
+	if q == nil || q.params == nil {
+		return csl
+	}
+	return q.params.Statement(csl)
+}
+
+// QueryParameters declares the query parameters of the query and sets their values in the request
+// properties.
+func QueryParameters(p *Parameters) QueryOption {
+	return func(q *QueryOptions) error {
+		values, err := p.Values()
+		if err != nil {
+			return err
+		}
+		q.RequestProperties.Parameters = values
+		q.params = p
+		return nil
+	}
+}
+
 // ClientRequestID sets the x-ms-client-request-id header, and can be used to identify the request in the `.show queries` output.
 func ClientRequestID(clientRequestID string) QueryOption {
 	return func(q *QueryOptions) error {
@@ -137,6 +225,25 @@ func DeferPartialQueryFailures() QueryOption {
 	}
 }
 
+// LenientPartialFailures reads the whole response when the service reports partial query failures inside
+// it, so that all the rows it holds are returned along with the *errors.PartialFailureError. By default,
+// reading stops at the first failure and the rows received before it are returned with the error.
+func LenientPartialFailures() QueryOption {
+	return func(q *QueryOptions) error {
+		q.LenientPartialFailures = true
+		return nil
+	}
+}
+
+// IteratorOptions configures the iterator returned by QueryIter, for instance to follow the progress of a
+// progressive query with frames.WithProgress.
+func IteratorOptions(options ...frames.IteratorOption) QueryOption {
+	return func(q *QueryOptions) error {
+		q.IteratorOptions = append(q.IteratorOptions, options...)
+		return nil
+	}
+}
+
 // MaxMemoryConsumptionPerQueryPerNode overrides the default maximum amount of memory a whole query
 // may allocate per node.
 func MaxMemoryConsumptionPerQueryPerNode(i uint64) QueryOption {
@@ -153,3 +260,366 @@ func MaxMemoryConsumptionPerIterator(i uint64) QueryOption {
 		return nil
 	}
 }
+
+// MaxOutputColumns overrides the default maximum number of columns a query is allowed to produce.
+func MaxOutputColumns(i int) QueryOption {
+	return func(q *QueryOptions) error {
+		if i <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", MaxOutputColumnsValue, i)
+		}
+		q.RequestProperties.Options[MaxOutputColumnsValue] = i
+		return nil
+	}
+}
+
+// PushSelectionThroughAggregation pushes simple selections through aggregations.
+func PushSelectionThroughAggregation() QueryOption {
+	return setOption(PushSelectionThroughAggregationValue, true)
+}
+
+// QueryCursorAfterDefault sets the default parameter value of the cursor_after() function when called
+// without parameters.
+func QueryCursorAfterDefault(cursor string) QueryOption {
+	return setOption(QueryCursorAfterDefaultValue, cursor)
+}
+
+// QueryCursorBeforeOrAtDefault sets the default parameter value of the cursor_before_or_at() function when
+// called without parameters.
+func QueryCursorBeforeOrAtDefault(cursor string) QueryOption {
+	return setOption(QueryCursorBeforeOrAtDefaultValue, cursor)
+}
+
+// QueryCursorCurrent overrides the cursor value returned by the cursor_current() function.
+func QueryCursorCurrent(cursor string) QueryOption {
+	return setOption(QueryCursorCurrentValue, cursor)
+}
+
+// QueryCursorDisabled disables the usage of cursor functions in the context of the query.
+func QueryCursorDisabled() QueryOption {
+	return setOption(QueryCursorDisabledValue, true)
+}
+
+// QueryCursorScopedTables restricts the cursor functions to the given tables.
+func QueryCursorScopedTables(tables ...string) QueryOption {
+	return func(q *QueryOptions) error {
+		if len(tables) == 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s needs at least one table", QueryCursorScopedTablesValue)
+		}
+		q.RequestProperties.Options[QueryCursorScopedTablesValue] = tables
+		return nil
+	}
+}
+
+// QueryDatascope controls whether the query applies to all the data or only to the data in the hot cache.
+func QueryDatascope(ds DataScope) QueryOption {
+	return func(q *QueryOptions) error {
+		switch ds {
+		case DataScopeDefault, DataScopeAll, DataScopeHotCache:
+		default:
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be one of default, all or hotcache, got %q", QueryDatascopeValue, ds)
+		}
+		q.RequestProperties.Options[QueryDatascopeValue] = string(ds)
+		return nil
+	}
+}
+
+// QueryDateTimeScopeColumn sets the column the query datetime scope, set with QueryDateTimeScopeFrom and
+// QueryDateTimeScopeTo, applies to.
+func QueryDateTimeScopeColumn(column string) QueryOption {
+	return func(q *QueryOptions) error {
+		if column == "" {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s cannot be empty", QueryDateTimeScopeColumnValue)
+		}
+		q.RequestProperties.Options[QueryDateTimeScopeColumnValue] = column
+		return nil
+	}
+}
+
+// QueryDateTimeScopeFrom sets the minimum date and time of the query datetime scope.
+func QueryDateTimeScopeFrom(t time.Time) QueryOption {
+	return setDateTime(QueryDateTimeScopeFromValue, t)
+}
+
+// QueryDateTimeScopeTo sets the maximum date and time of the query datetime scope.
+func QueryDateTimeScopeTo(t time.Time) QueryOption {
+	return setDateTime(QueryDateTimeScopeToValue, t)
+}
+
+// ClientMaxRedirectCount sets the maximum number of HTTP redirects the client follows.
+func ClientMaxRedirectCount(i int) QueryOption {
+	return func(q *QueryOptions) error {
+		if i < 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s cannot be negative, got %d", ClientMaxRedirectCountValue, i)
+		}
+		q.RequestProperties.Options[ClientMaxRedirectCountValue] = i
+		return nil
+	}
+}
+
+// MaterializedViewShuffle sets the shuffle hint of the materialized views referenced by the query. hint is
+// marshaled to JSON.
+func MaterializedViewShuffle(hint interface{}) QueryOption {
+	return func(q *QueryOptions) error {
+		b, err := json.Marshal(hint)
+		if err != nil {
+			return errors.ErrWrapf(err, "%s could not be marshaled", MaterializedViewShuffleValue)
+		}
+		q.RequestProperties.Options[MaterializedViewShuffleValue] = json.RawMessage(b)
+		return nil
+	}
+}
+
+// QueryBinAutoAt sets the start value the bin_auto() function uses, as a literal such as "datetime(2023-01-01)".
+func QueryBinAutoAt(literal string) QueryOption {
+	return setOption(QueryBinAutoAtValue, literal)
+}
+
+// QueryBinAutoSize sets the bin size the bin_auto() function uses, as a literal such as "1h".
+func QueryBinAutoSize(literal string) QueryOption {
+	return setOption(QueryBinAutoSizeValue, literal)
+}
+
+// QueryDistributionNodesSpan controls the behavior of the sub-query merge: the number of nodes each
+// executing node aggregates the results of.
+func QueryDistributionNodesSpan(i int) QueryOption {
+	return func(q *QueryOptions) error {
+		if i <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", QueryDistributionNodesSpanValue, i)
+		}
+		q.RequestProperties.Options[QueryDistributionNodesSpanValue] = i
+		return nil
+	}
+}
+
+// QueryFanoutNodesPercent sets the percentage of nodes the query is distributed to.
+func QueryFanoutNodesPercent(percent int) QueryOption {
+	return setPercent(QueryFanoutNodesPercentValue, percent)
+}
+
+// QueryFanoutThreadsPercent sets the percentage of threads the query is distributed to on each node.
+func QueryFanoutThreadsPercent(percent int) QueryOption {
+	return setPercent(QueryFanoutThreadsPercentValue, percent)
+}
+
+// QueryForceRowLevelSecurity enforces the row level security rules, even if the policy is disabled.
+func QueryForceRowLevelSecurity() QueryOption {
+	return setOption(QueryForceRowLevelSecurityValue, true)
+}
+
+// QueryLanguage sets the language the query is written in.
+func QueryLanguage(l Language) QueryOption {
+	return func(q *QueryOptions) error {
+		switch l {
+		case LanguageKQL, LanguageCSL, LanguageSQL:
+		default:
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be one of kql, csl or sql, got %q", QueryLanguageValue, l)
+		}
+		q.RequestProperties.Options[QueryLanguageValue] = string(l)
+		return nil
+	}
+}
+
+// QueryLogQueryParameters logs the query parameters, so that they appear in the `.show queries` output.
+func QueryLogQueryParameters() QueryOption {
+	return setOption(QueryLogQueryParametersValue, true)
+}
+
+// QueryMaxEntitiesInUnion overrides the default maximum number of columns a union may produce.
+func QueryMaxEntitiesInUnion(i int64) QueryOption {
+	return func(q *QueryOptions) error {
+		if i <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", QueryMaxEntitiesInUnionValue, i)
+		}
+		q.RequestProperties.Options[QueryMaxEntitiesInUnionValue] = i
+		return nil
+	}
+}
+
+// QueryNow overrides the value returned by the now() function.
+func QueryNow(t time.Time) QueryOption {
+	return setDateTime(QueryNowValue, t)
+}
+
+// QueryPythonDebug generates a debug query for the given python node, the first one being 1.
+func QueryPythonDebug(node int) QueryOption {
+	return func(q *QueryOptions) error {
+		if node <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", QueryPythonDebugValue, node)
+		}
+		q.RequestProperties.Options[QueryPythonDebugValue] = node
+		return nil
+	}
+}
+
+// QueryResultsApplyGetschema returns the schema of the tabular results of the query instead of its data.
+func QueryResultsApplyGetschema() QueryOption {
+	return setOption(QueryResultsApplyGetschemaValue, true)
+}
+
+// QueryResultsCacheMaxAge enables the query results cache, accepting results cached for at most d.
+func QueryResultsCacheMaxAge(d time.Duration) QueryOption {
+	return setTimespan(QueryResultsCacheMaxAgeValue, d)
+}
+
+// QueryResultsCachePerShard enables the per extent query results cache.
+func QueryResultsCachePerShard() QueryOption {
+	return setOption(QueryResultsCachePerShardValue, true)
+}
+
+// QueryResultsProgressiveRowCount hints how many records are sent in each update of a progressive query.
+func QueryResultsProgressiveRowCount(i int64) QueryOption {
+	return func(q *QueryOptions) error {
+		if i <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", QueryResultsProgressiveRowCountValue, i)
+		}
+		q.RequestProperties.Options[QueryResultsProgressiveRowCountValue] = i
+		return nil
+	}
+}
+
+// QueryResultsProgressiveUpdatePeriod hints how often progress frames are sent in a progressive query.
+func QueryResultsProgressiveUpdatePeriod(d time.Duration) QueryOption {
+	return setTimespan(QueryResultsProgressiveUpdatePeriodValue, d)
+}
+
+// QueryTakeMaxRecords limits the query results to i records.
+func QueryTakeMaxRecords(i int64) QueryOption {
+	return func(q *QueryOptions) error {
+		if i < 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s cannot be negative, got %d", QueryTakeMaxRecordsValue, i)
+		}
+		q.RequestProperties.Options[QueryTakeMaxRecordsValue] = i
+		return nil
+	}
+}
+
+// QueryConsistency controls the consistency of the query results.
+func QueryConsistency(c Consistency) QueryOption {
+	return func(q *QueryOptions) error {
+		switch c {
+		case ConsistencyStrong, ConsistencyWeak, ConsistencyAffinitizedWeak, ConsistencyDatabaseAffinitizedWeak:
+		default:
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s %q is not valid", QueryConsistencyValue, c)
+		}
+		q.RequestProperties.Options[QueryConsistencyValue] = string(c)
+		return nil
+	}
+}
+
+// RequestAppName sets the application name reported in the `.show queries` output.
+func RequestAppName(name string) QueryOption {
+	return setOption(RequestAppNameValue, name)
+}
+
+// RequestBlockRowLevelSecurity blocks the access to tables with a row level security policy.
+func RequestBlockRowLevelSecurity() QueryOption {
+	return setOption(RequestBlockRowLevelSecurityValue, true)
+}
+
+// RequestCalloutDisabled prevents the request from accessing the callout plugins.
+func RequestCalloutDisabled() QueryOption {
+	return setOption(RequestCalloutDisabledValue, true)
+}
+
+// RequestDescription sets a description of the request, reported in the `.show queries` output.
+func RequestDescription(description string) QueryOption {
+	return setOption(RequestDescriptionValue, description)
+}
+
+// RequestExternalTableDisabled prevents the request from accessing external tables.
+func RequestExternalTableDisabled() QueryOption {
+	return setOption(RequestExternalTableDisabledValue, true)
+}
+
+// RequestImpersonationDisabled disables the impersonation of the caller when accessing external resources.
+func RequestImpersonationDisabled() QueryOption {
+	return setOption(RequestImpersonationDisabledValue, true)
+}
+
+// RequestReadonly prevents the request from writing anything.
+func RequestReadonly() QueryOption {
+	return setOption(RequestReadonlyValue, true)
+}
+
+// RequestRemoteEntitiesDisabled prevents the request from accessing remote databases and clusters.
+func RequestRemoteEntitiesDisabled() QueryOption {
+	return setOption(RequestRemoteEntitiesDisabledValue, true)
+}
+
+// RequestSandboxedExecutionDisabled prevents the request from invoking code in the sandbox.
+func RequestSandboxedExecutionDisabled() QueryOption {
+	return setOption(RequestSandboxedExecutionDisabledValue, true)
+}
+
+// RequestUser sets the user name reported in the `.show queries` output.
+func RequestUser(user string) QueryOption {
+	return setOption(RequestUserValue, user)
+}
+
+// TruncationMaxRecords overrides the default maximum number of records a query may return.
+func TruncationMaxRecords(i int64) QueryOption {
+	return func(q *QueryOptions) error {
+		if i <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", TruncationMaxRecordsValue, i)
+		}
+		q.RequestProperties.Options[TruncationMaxRecordsValue] = i
+		return nil
+	}
+}
+
+// TruncationMaxSize overrides the default maximum size in bytes of the data a query may return.
+func TruncationMaxSize(i int64) QueryOption {
+	return func(q *QueryOptions) error {
+		if i <= 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be positive, got %d", TruncationMaxSizeValue, i)
+		}
+		q.RequestProperties.Options[TruncationMaxSizeValue] = i
+		return nil
+	}
+}
+
+// ValidatePermissions validates the permissions of the user on the query without running it.
+func ValidatePermissions() QueryOption {
+	return setOption(ValidatePermissionsValue, true)
+}
+
+// setOption returns a QueryOption setting the request property name to v.
+func setOption(name string, v interface{}) QueryOption {
+	return func(q *QueryOptions) error {
+		q.RequestProperties.Options[name] = v
+		return nil
+	}
+}
+
+// setDateTime returns a QueryOption setting the request property name to the datetime t.
+func setDateTime(name string, t time.Time) QueryOption {
+	return func(q *QueryOptions) error {
+		if t.IsZero() {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s cannot be the zero time", name)
+		}
+		q.RequestProperties.Options[name] = t.UTC().Format(time.RFC3339Nano)
+		return nil
+	}
+}
+
+// setTimespan returns a QueryOption setting the request property name to the timespan d.
+func setTimespan(name string, d time.Duration) QueryOption {
+	return func(q *QueryOptions) error {
+		if d < 0 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s cannot be negative, got %s", name, d)
+		}
+		q.RequestProperties.Options[name] = value.Timespan{Valid: true, Value: d}.Marshal()
+		return nil
+	}
+}
+
+// setPercent returns a QueryOption setting the request property name to the percentage percent.
+func setPercent(name string, percent int) QueryOption {
+	return func(q *QueryOptions) error {
+		if percent < 1 || percent > 100 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "%s must be between 1 and 100, got %d", name, percent)
+		}
+		q.RequestProperties.Options[name] = percent
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package query
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryOptionsJSON(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 30, 0, 500, time.FixedZone("UTC+2", 2*60*60))
+
+	tests := []struct {
+		option QueryOption
+		name   string
+		want   string
+	}{
+		{NoRequestTimeout(), NoRequestTimeoutValue, `true`},
+		{NoTruncation(), NoTruncationValue, `true`},
+		{ResultsProgressiveEnabled(), ResultsProgressiveEnabledValue, `true`},
+		{ServerTimeout(90 * time.Second), ServerTimeoutValue, `"00:01:30"`},
+		{DeferPartialQueryFailures(), DeferPartialQueryFailuresValue, `true`},
+		{MaxMemoryConsumptionPerQueryPerNode(1 << 30), MaxMemoryConsumptionPerQueryPerNodeValue, `1073741824`},
+		{MaxMemoryConsumptionPerIterator(1 << 20), MaxMemoryConsumptionPerIteratorValue, `1048576`},
+		{MaxOutputColumns(10), MaxOutputColumnsValue, `10`},
+		{PushSelectionThroughAggregation(), PushSelectionThroughAggregationValue, `true`},
+		{QueryCursorAfterDefault("cursor1"), QueryCursorAfterDefaultValue, `"cursor1"`},
+		{QueryCursorBeforeOrAtDefault("cursor2"), QueryCursorBeforeOrAtDefaultValue, `"cursor2"`},
+		{QueryCursorCurrent("cursor3"), QueryCursorCurrentValue, `"cursor3"`},
+		{QueryCursorDisabled(), QueryCursorDisabledValue, `true`},
+		{QueryCursorScopedTables("T1", "T2"), QueryCursorScopedTablesValue, `["T1","T2"]`},
+		{QueryDatascope(DataScopeHotCache), QueryDatascopeValue, `"hotcache"`},
+		{QueryDateTimeScopeColumn("Timestamp"), QueryDateTimeScopeColumnValue, `"Timestamp"`},
+		{QueryDateTimeScopeFrom(now), QueryDateTimeScopeFromValue, `"2023-05-01T10:30:00.0000005Z"`},
+		{QueryDateTimeScopeTo(now), QueryDateTimeScopeToValue, `"2023-05-01T10:30:00.0000005Z"`},
+		{ClientMaxRedirectCount(0), ClientMaxRedirectCountValue, `0`},
+		{MaterializedViewShuffle(map[string]interface{}{"Name": "View", "Keys": []string{"K"}}), MaterializedViewShuffleValue, `{"Keys":["K"],"Name":"View"}`},
+		{QueryBinAutoAt("datetime(2023-01-01)"), QueryBinAutoAtValue, `"datetime(2023-01-01)"`},
+		{QueryBinAutoSize("1h"), QueryBinAutoSizeValue, `"1h"`},
+		{QueryDistributionNodesSpan(4), QueryDistributionNodesSpanValue, `4`},
+		{QueryFanoutNodesPercent(50), QueryFanoutNodesPercentValue, `50`},
+		{QueryFanoutThreadsPercent(100), QueryFanoutThreadsPercentValue, `100`},
+		{QueryForceRowLevelSecurity(), QueryForceRowLevelSecurityValue, `true`},
+		{QueryLanguage(LanguageSQL), QueryLanguageValue, `"sql"`},
+		{QueryLogQueryParameters(), QueryLogQueryParametersValue, `true`},
+		{QueryMaxEntitiesInUnion(100), QueryMaxEntitiesInUnionValue, `100`},
+		{QueryNow(now), QueryNowValue, `"2023-05-01T10:30:00.0000005Z"`},
+		{QueryPythonDebug(2), QueryPythonDebugValue, `2`},
+		{QueryResultsApplyGetschema(), QueryResultsApplyGetschemaValue, `true`},
+		{QueryResultsCacheMaxAge(36 * time.Hour), QueryResultsCacheMaxAgeValue, `"1.12:00:00"`},
+		{QueryResultsCachePerShard(), QueryResultsCachePerShardValue, `true`},
+		{QueryResultsProgressiveRowCount(500), QueryResultsProgressiveRowCountValue, `500`},
+		{QueryResultsProgressiveUpdatePeriod(1500 * time.Millisecond), QueryResultsProgressiveUpdatePeriodValue, `"00:00:01.5"`},
+		{QueryTakeMaxRecords(1000), QueryTakeMaxRecordsValue, `1000`},
+		{QueryConsistency(ConsistencyWeak), QueryConsistencyValue, `"weakconsistency"`},
+		{RequestAppName("app"), RequestAppNameValue, `"app"`},
+		{RequestBlockRowLevelSecurity(), RequestBlockRowLevelSecurityValue, `true`},
+		{RequestCalloutDisabled(), RequestCalloutDisabledValue, `true`},
+		{RequestDescription("nightly report"), RequestDescriptionValue, `"nightly report"`},
+		{RequestExternalTableDisabled(), RequestExternalTableDisabledValue, `true`},
+		{RequestImpersonationDisabled(), RequestImpersonationDisabledValue, `true`},
+		{RequestReadonly(), RequestReadonlyValue, `true`},
+		{RequestRemoteEntitiesDisabled(), RequestRemoteEntitiesDisabledValue, `true`},
+		{RequestSandboxedExecutionDisabled(), RequestSandboxedExecutionDisabledValue, `true`},
+		{RequestUser("user"), RequestUserValue, `"user"`},
+		{TruncationMaxRecords(5000), TruncationMaxRecordsValue, `5000`},
+		{TruncationMaxSize(1 << 26), TruncationMaxSizeValue, `67108864`},
+		{ValidatePermissions(), ValidatePermissionsValue, `true`},
+	}
+
+	tested := map[string]bool{}
+	for _, test := range tests {
+		tested[test.name] = true
+
+		q, err := NewQueryOptions(test.option)
+		if err != nil {
+			t.Errorf("%s: NewQueryOptions() returned error: %v", test.name, err)
+			continue
+		}
+
+		b, err := json.Marshal(q.RequestProperties)
+		if err != nil {
+			t.Errorf("%s: failed to marshal request properties: %v", test.name, err)
+			continue
+		}
+
+		var props struct {
+			Options map[string]json.RawMessage
+		}
+		if err := json.Unmarshal(b, &props); err != nil {
+			t.Fatalf("%s: failed to unmarshal %s: %v", test.name, b, err)
+		}
+		if len(props.Options) != 1 || string(props.Options[test.name]) != test.want {
+			t.Errorf("%s: Options = %s, want {%q:%s}", test.name, b, test.name, test.want)
+		}
+	}
+
+	for _, name := range declaredOptions(t) {
+		if !tested[name] {
+			t.Errorf("request property %s has no tested QueryOption", name)
+		}
+	}
+}
+
+// declaredOptions returns the values of the *Value constants declared in query_options.go.
+func declaredOptions(t *testing.T) []string {
+	t.Helper()
+
+	f, err := parser.ParseFile(token.NewFileSet(), "query_options.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse query_options.go: %v", err)
+	}
+
+	var names []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, ident := range vs.Names {
+				if !strings.HasSuffix(ident.Name, "Value") || i >= len(vs.Values) {
+					continue
+				}
+				if lit, ok := vs.Values[i].(*ast.BasicLit); ok {
+					names = append(names, strings.Trim(lit.Value, `"`))
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestQueryOptionsValidation(t *testing.T) {
+	tests := map[string]QueryOption{
+		"datascope":             QueryDatascope("cold"),
+		"consistency":           QueryConsistency("eventual"),
+		"language":              QueryLanguage("python"),
+		"zero query_now":        QueryNow(time.Time{}),
+		"zero datetime scope":   QueryDateTimeScopeFrom(time.Time{}),
+		"negative cache age":    QueryResultsCacheMaxAge(-time.Minute),
+		"negative update":       QueryResultsProgressiveUpdatePeriod(-time.Second),
+		"percent above 100":     QueryFanoutNodesPercent(101),
+		"percent zero":          QueryFanoutThreadsPercent(0),
+		"no scoped tables":      QueryCursorScopedTables(),
+		"empty datetime column": QueryDateTimeScopeColumn(""),
+		"zero max records":      TruncationMaxRecords(0),
+		"negative take":         QueryTakeMaxRecords(-1),
+		"invalid shuffle":       MaterializedViewShuffle(func() {}),
+	}
+
+	for name, option := range tests {
+		if _, err := NewQueryOptions(option); err == nil {
+			t.Errorf("%s: NewQueryOptions() returned no error", name)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package table
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
+// tagName is the struct tag used to map a column to a field.
+const tagName = "kusto"
+
+// fieldsCache caches the column name to field mapping of the struct types rows are decoded into.
+var fieldsCache sync.Map // map[reflect.Type]map[string]int
+
+// ToStruct stores the values of the row in the fields of the struct pointed to by p. Columns are mapped
+// to fields by their `kusto:"column"` tag, or by their name when the field has no tag. Fields tagged with
+// `kusto:"-"` and columns without a matching field are ignored.
+//
+// Each value is converted with value.Value.Convert, so a field may be of the native Go type of the column,
+// a pointer to it, or the value type itself.
+func (r *Row) ToStruct(p interface{}) error {
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.ErrWrapf(errors.ErrInvalidType, "ToStruct requires a non-nil pointer to a struct, got %T", p)
+	}
+
+	return r.decode(v.Elem())
+}
+
+// ToStructs stores the rows of the table in the slice pointed to by p, which must be a *[]T or a *[]*T
+// where T is a struct. Rows are decoded as described in Row.ToStruct and appended to the slice.
+func (t *Table) ToStructs(p interface{}) error {
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return errors.ErrWrapf(errors.ErrInvalidType, "ToStructs requires a non-nil pointer to a slice, got %T", p)
+	}
+
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	if isPtr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return errors.ErrWrapf(errors.ErrInvalidType, "ToStructs requires a slice of structs or struct pointers, got %T", p)
+	}
+
+	for i, row := range t.Rows {
+		s := reflect.New(elem)
+		if err := row.decode(s.Elem()); err != nil {
+			return errors.ErrWrapf(err, "row %d", i)
+		}
+		if isPtr {
+			slice = reflect.Append(slice, s)
+		} else {
+			slice = reflect.Append(slice, s.Elem())
+		}
+	}
+
+	v.Elem().Set(slice)
+	return nil
+}
+
+// decode stores the values of the row in the fields of the struct s.
+func (r *Row) decode(s reflect.Value) error {
+	if len(r.Values) != len(r.ColumnTypes) {
+		return errors.ErrWrapf(errors.ErrInvalidType, "row has %d values, but %d columns", len(r.Values), len(r.ColumnTypes))
+	}
+
+	fields := structFields(s.Type())
+
+	for i, col := range r.ColumnTypes {
+		idx, ok := fields[col.Name]
+		if !ok {
+			continue
+		}
+
+		f := s.Field(idx)
+		if err := r.Values[i].Convert(f); err != nil {
+			return errors.ErrWrapf(err, "column %q (%s) cannot be stored in field %s.%s (%s)",
+				col.Name, col.Type, s.Type().Name(), s.Type().Field(idx).Name, f.Type())
+		}
+	}
+
+	return nil
+}
+
+// structFields returns the mapping of column names to field indexes for the struct type t.
+func structFields(t reflect.Type) map[string]int {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.(map[string]int)
+	}
+
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup(tagName); ok {
+			tag = strings.TrimSpace(strings.Split(tag, ",")[0])
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields[name] = i
+	}
+
+	fieldsCache.Store(t, fields)
+	return fields
+}
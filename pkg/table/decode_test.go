@@ -0,0 +1,114 @@
+package table
+
+import (
+	"testing"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+	"github.com/google/uuid"
+)
+
+type record struct {
+	Name     string         `kusto:"name"`
+	Count    *int64         `kusto:"count"`
+	Missing  *int64         `kusto:"missing"`
+	Enabled  value.Bool     `kusto:"enabled"`
+	Started  time.Time      `kusto:"started"`
+	Duration time.Duration  `kusto:"duration"`
+	ID       uuid.UUID      `kusto:"id"`
+	Price    value.Decimal  `kusto:"price"`
+	Props    map[string]int `kusto:"props"`
+	Ignored  string         `kusto:"-"`
+	Untagged float64
+}
+
+func testTable() *Table {
+	cols := Columns{
+		{Name: "name", Type: types.String},
+		{Name: "count", Type: types.Long},
+		{Name: "missing", Type: types.Long},
+		{Name: "enabled", Type: types.Bool},
+		{Name: "started", Type: types.DateTime},
+		{Name: "duration", Type: types.Timespan},
+		{Name: "id", Type: types.GUID},
+		{Name: "price", Type: types.Decimal},
+		{Name: "props", Type: types.Dynamic},
+		{Name: "-", Type: types.String},
+		{Name: "Untagged", Type: types.Real},
+		{Name: "extra", Type: types.Int},
+	}
+
+	return &Table{
+		Columns: cols,
+		Rows: []*Row{
+			{
+				ColumnTypes: cols,
+				Values: value.Values{
+					&value.String{Value: "a", Valid: true},
+					&value.Long{Value: 7, Valid: true},
+					&value.Long{},
+					&value.Bool{Value: true, Valid: true},
+					&value.DateTime{Value: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true},
+					&value.Timespan{Value: time.Minute, Valid: true},
+					&value.GUID{Value: uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), Valid: true},
+					&value.Decimal{Value: "1.5", Valid: true},
+					&value.Dynamic{Value: []byte(`{"x":1}`), Valid: true},
+					&value.String{Value: "ignored", Valid: true},
+					&value.Real{Value: 2.5, Valid: true},
+					&value.Int{Value: 1, Valid: true},
+				},
+			},
+		},
+	}
+}
+
+func TestToStructs(t *testing.T) {
+	var got []record
+	if err := testTable().ToStructs(&got); err != nil {
+		t.Fatalf("ToStructs() returned error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d records, want 1", len(got))
+	}
+
+	r := got[0]
+	if r.Name != "a" || r.Count == nil || *r.Count != 7 || r.Missing != nil {
+		t.Errorf("unexpected name/count/missing: %+v", r)
+	}
+	if !r.Enabled.Valid || !r.Enabled.Value {
+		t.Errorf("Enabled = %+v, want true", r.Enabled)
+	}
+	if r.Started.Year() != 2023 || r.Duration != time.Minute || r.ID.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Errorf("unexpected started/duration/id: %+v", r)
+	}
+	if r.Price.Value != "1.5" || r.Props["x"] != 1 || r.Ignored != "" || r.Untagged != 2.5 {
+		t.Errorf("unexpected price/props/ignored/untagged: %+v", r)
+	}
+
+	var ptrs []*record
+	if err := testTable().ToStructs(&ptrs); err != nil || len(ptrs) != 1 || ptrs[0].Name != "a" {
+		t.Errorf("ToStructs(*[]*record) = %v, %+v", err, ptrs)
+	}
+}
+
+func TestToStructErrors(t *testing.T) {
+	row := testTable().Rows[0]
+
+	var mismatch struct {
+		Name int `kusto:"name"`
+	}
+	if err := row.ToStruct(&mismatch); err == nil {
+		t.Errorf("ToStruct() with mismatched field succeeded, want error")
+	}
+
+	var notPtr record
+	if err := row.ToStruct(notPtr); err == nil {
+		t.Errorf("ToStruct() with non-pointer succeeded, want error")
+	}
+
+	var notSlice record
+	if err := testTable().ToStructs(&notSlice); err == nil {
+		t.Errorf("ToStructs() with non-slice succeeded, want error")
+	}
+}
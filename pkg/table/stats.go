@@ -0,0 +1,181 @@
+package table
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+// resourceConsumptionEvent is the EventTypeName of the QueryCompletionInformation row holding the
+// resource consumption of the query.
+const resourceConsumptionEvent = "QueryResourceConsumption"
+
+// QueryStats holds the resources consumed by a query, as reported in the QueryCompletionInformation table.
+type QueryStats struct {
+	// ClientRequestID is the client request ID of the query.
+	ClientRequestID string
+	// ActivityID is the ID the service assigned to the query.
+	ActivityID string
+	// ExecutionTime is the time the query took to execute.
+	ExecutionTime time.Duration
+	// CPU holds the CPU time the query consumed.
+	CPU CPUStats
+	// MemoryPeakPerNode is the peak memory, in bytes, the query used on a single node.
+	MemoryPeakPerNode int64
+	// Cache holds the cache hits and misses of the query.
+	Cache CacheStats
+	// Extents holds the number of extents the query scanned.
+	Extents ScanStats
+	// Rows holds the number of rows the query scanned.
+	Rows ScanStats
+	// InterClusterBytes is the number of bytes exchanged between the nodes of the cluster.
+	InterClusterBytes int64
+	// CrossClusterBytes is the number of bytes exchanged with other clusters.
+	CrossClusterBytes int64
+	// Payload holds the raw JSON the statistics were parsed from.
+	Payload []byte
+}
+
+// CPUStats holds the CPU time consumed by a query.
+type CPUStats struct {
+	User   time.Duration
+	Kernel time.Duration
+	Total  time.Duration
+}
+
+// CacheStats holds the cache hits and misses of a query.
+type CacheStats struct {
+	MemoryHits   int64
+	MemoryMisses int64
+	DiskHits     int64
+	DiskMisses   int64
+	// HotHitBytes and HotMissBytes are the bytes of hot shards found in, and missing from, the cache.
+	HotHitBytes  int64
+	HotMissBytes int64
+	// ColdHitBytes and ColdMissBytes are the bytes of cold shards found in, and missing from, the cache.
+	ColdHitBytes  int64
+	ColdMissBytes int64
+}
+
+// ScanStats holds how many of the items in scope a query scanned.
+type ScanStats struct {
+	Total   int64
+	Scanned int64
+}
+
+// resourceConsumption is the payload of the QueryResourceConsumption event.
+type resourceConsumption struct {
+	ExecutionTime float64 `json:"ExecutionTime"`
+	ResourceUsage struct {
+		Cache struct {
+			Memory hitsMisses `json:"memory"`
+			Disk   hitsMisses `json:"disk"`
+			Shards struct {
+				Hot  shardBytes `json:"hot"`
+				Cold shardBytes `json:"cold"`
+			} `json:"shards"`
+		} `json:"cache"`
+		CPU struct {
+			User   value.Timespan `json:"user"`
+			Kernel value.Timespan `json:"kernel"`
+			Total  value.Timespan `json:"total cpu"`
+		} `json:"cpu"`
+		Memory struct {
+			PeakPerNode int64 `json:"peak_per_node"`
+		} `json:"memory"`
+		Network struct {
+			InterClusterTotalBytes int64 `json:"inter_cluster_total_bytes"`
+			CrossClusterTotalBytes int64 `json:"cross_cluster_total_bytes"`
+		} `json:"network"`
+	} `json:"resource_usage"`
+	InputDatasetStatistics struct {
+		Extents scan `json:"extents"`
+		Rows    scan `json:"rows"`
+	} `json:"input_dataset_statistics"`
+}
+
+type hitsMisses struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+type shardBytes struct {
+	HitBytes  int64 `json:"hitbytes"`
+	MissBytes int64 `json:"missbytes"`
+}
+
+type scan struct {
+	Total   int64 `json:"total"`
+	Scanned int64 `json:"scanned"`
+}
+
+// QueryStatsFromRow returns the statistics held by a row of the QueryCompletionInformation table, or nil
+// if the row is not the QueryResourceConsumption event.
+func QueryStatsFromRow(r *Row) (*QueryStats, error) {
+	if stringValue(r.ValueByName("EventTypeName")) != resourceConsumptionEvent {
+		return nil, nil
+	}
+
+	payload := r.ValueByName("Payload")
+	if payload == nil {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "%s event has no Payload", resourceConsumptionEvent)
+	}
+
+	b := []byte(payload.String())
+	rc := resourceConsumption{}
+	if err := json.Unmarshal(b, &rc); err != nil {
+		return nil, errors.ErrWrapf(err, "failed to decode %s payload", resourceConsumptionEvent)
+	}
+
+	usage := rc.ResourceUsage
+	s := &QueryStats{
+		ClientRequestID: stringValue(r.ValueByName("ClientRequestId")),
+		ActivityID:      stringValue(r.ValueByName("ActivityId")),
+		ExecutionTime:   time.Duration(rc.ExecutionTime * float64(time.Second)),
+		CPU: CPUStats{
+			User:   usage.CPU.User.Value,
+			Kernel: usage.CPU.Kernel.Value,
+			Total:  usage.CPU.Total.Value,
+		},
+		MemoryPeakPerNode: usage.Memory.PeakPerNode,
+		Cache: CacheStats{
+			MemoryHits:    usage.Cache.Memory.Hits,
+			MemoryMisses:  usage.Cache.Memory.Misses,
+			DiskHits:      usage.Cache.Disk.Hits,
+			DiskMisses:    usage.Cache.Disk.Misses,
+			HotHitBytes:   usage.Cache.Shards.Hot.HitBytes,
+			HotMissBytes:  usage.Cache.Shards.Hot.MissBytes,
+			ColdHitBytes:  usage.Cache.Shards.Cold.HitBytes,
+			ColdMissBytes: usage.Cache.Shards.Cold.MissBytes,
+		},
+		Extents:           ScanStats(rc.InputDatasetStatistics.Extents),
+		Rows:              ScanStats(rc.InputDatasetStatistics.Rows),
+		InterClusterBytes: usage.Network.InterClusterTotalBytes,
+		CrossClusterBytes: usage.Network.CrossClusterTotalBytes,
+		Payload:           b,
+	}
+
+	return s, nil
+}
+
+// QueryStatsFromTable returns the statistics held by the QueryCompletionInformation table t, or nil if it
+// holds none.
+func QueryStatsFromTable(t *Table) (*QueryStats, error) {
+	for _, r := range t.Rows {
+		s, err := QueryStatsFromRow(r)
+		if err != nil || s != nil {
+			return s, err
+		}
+	}
+	return nil, nil
+}
+
+// stringValue returns the string of v, or an empty string if v is nil.
+func stringValue(v value.Value) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
@@ -0,0 +1,104 @@
+// Package table provides the in-memory representation of Kusto result tables.
+package table
+
+import (
+	"github.com/crodriguezde/go-kusto/pkg/types"
+	"github.com/crodriguezde/go-kusto/pkg/value"
+)
+
+// Kinds of tables returned in a v2 query response.
+const (
+	KindPrimaryResult              = "PrimaryResult"
+	KindQueryProperties            = "QueryProperties"
+	KindQueryCompletionInformation = "QueryCompletionInformation"
+)
+
+// Column describes a column in a table.
+type Column struct {
+	// Name is the name of the column.
+	Name string `json:"ColumnName"`
+	// Type is the Kusto type of the column.
+	Type types.Column `json:"ColumnType"`
+}
+
+// Columns is a set of columns.
+type Columns []Column
+
+// Names returns the names of the columns in order.
+func (c Columns) Names() []string {
+	names := make([]string, 0, len(c))
+	for _, col := range c {
+		names = append(names, col.Name)
+	}
+	return names
+}
+
+// Index returns the position of the column with the given name, or -1 if it does not exist.
+func (c Columns) Index(name string) int {
+	for i, col := range c {
+		if col.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Row is a row of values in a table.
+type Row struct {
+	// ColumnTypes describes the columns of the row.
+	ColumnTypes Columns
+	// Values holds the values of the row, in the same order as ColumnTypes.
+	Values value.Values
+}
+
+// ValueByName returns the value of the column with the given name, or nil if it does not exist.
+func (r *Row) ValueByName(name string) value.Value {
+	i := r.ColumnTypes.Index(name)
+	if i < 0 || i >= len(r.Values) {
+		return nil
+	}
+	return r.Values[i]
+}
+
+// Table is a result table returned by Kusto.
+type Table struct {
+	// ID is the id of the table within the dataset.
+	ID int
+	// Name is the name of the table.
+	Name string
+	// Kind is the kind of the table, such as PrimaryResult.
+	Kind string
+	// Columns describes the columns of the table.
+	Columns Columns
+	// Rows holds the rows of the table.
+	Rows []*Row
+}
+
+// Dataset is the set of tables returned by a query.
+type Dataset struct {
+	// Tables holds all the tables of the dataset, in the order they were received.
+	Tables []*Table
+	// Stats holds the resources consumed by the query, when the service reported them.
+	Stats *QueryStats
+}
+
+// PrimaryResults returns the tables holding the results of the query.
+func (d *Dataset) PrimaryResults() []*Table {
+	var tables []*Table
+	for _, t := range d.Tables {
+		if t.Kind == KindPrimaryResult {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// TableByKind returns the first table of the given kind, or nil if there is none.
+func (d *Dataset) TableByKind(kind string) *Table {
+	for _, t := range d.Tables {
+		if t.Kind == kind {
+			return t
+		}
+	}
+	return nil
+}
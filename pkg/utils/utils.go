@@ -4,13 +4,10 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf16"
 )
 
 func QuoteString(value string, hidden bool) string {
-	if value == "" {
-		return value
-	}
-
 	var literal strings.Builder
 
 	if hidden {
@@ -55,6 +52,10 @@ func QuoteString(value string, hidden bool) string {
 		default:
 			if !ShouldBeEscaped(c) {
 				literal.WriteString(string(c))
+			} else if c > 0xffff {
+				// \u takes exactly four hex digits: runes outside the BMP are escaped as a UTF-16 surrogate pair.
+				r1, r2 := utf16.EncodeRune(c)
+				literal.WriteString(fmt.Sprintf("\\u%04x\\u%04x", r1, r2))
 			} else {
 				literal.WriteString(fmt.Sprintf("\\u%04x", c))
 			}
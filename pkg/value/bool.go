@@ -1,5 +1,13 @@
 package value
 
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
 // Bool represents a Kusto boolean type. Bool implements Kusto.
 type Bool struct {
 	// Value holds the value of the type.
@@ -20,3 +28,63 @@ func (bo Bool) String() string {
 	}
 	return "false"
 }
+
+// Unmarshal unmarshals i into Bool. i must be a bool, the json.Number 0 or 1 used for SByte columns of
+// the v1 endpoints, or nil.
+func (bo *Bool) Unmarshal(i interface{}) error {
+	if i == nil {
+		bo.Value = false
+		bo.Valid = false
+		return nil
+	}
+
+	switch v := i.(type) {
+	case bool:
+		bo.Value = v
+	case json.Number:
+		switch v {
+		case "0":
+			bo.Value = false
+		case "1":
+			bo.Value = true
+		default:
+			return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'bool' had value %s that is not 0 or 1", v)
+		}
+	default:
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'bool' had value that was %T", i)
+	}
+	bo.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a bool, an int64 holding 0 or 1, a string or []byte parsed with
+// strconv.ParseBool, or nil.
+func (bo *Bool) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil, bool:
+		return bo.Unmarshal(v)
+	case int64:
+		return bo.Unmarshal(json.Number(strconv.FormatInt(v, 10)))
+	}
+	if s, ok := scanText(src); ok {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.ErrWrapf(err, "value %q cannot be scanned into a value of type 'bool'", s)
+		}
+		return bo.Unmarshal(b)
+	}
+	return scanError("bool", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (bo *Bool) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(bo, b)
+}
+
+// Convert Bool into reflect value. v may be a bool, *bool, Bool or *Bool.
+func (bo Bool) Convert(v reflect.Value) error {
+	if convert(v, bo.Valid, bo.Value, bo) {
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'bool' could not be stored in %s", v.Type())
+}
@@ -0,0 +1,101 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type myString string
+
+func TestConvert(t *testing.T) {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	now := time.Date(2023, 12, 21, 0, 0, 0, 0, time.UTC)
+	i3, i64, s, f64 := int64(3), int64(9), "x", 1.5
+
+	tests := []struct {
+		name string
+		val  Value
+		dst  interface{}
+		want interface{}
+	}{
+		{name: "bool", val: &Bool{Value: true, Valid: true}, dst: new(bool), want: true},
+		{name: "bool value", val: &Bool{Value: true, Valid: true}, dst: new(Bool), want: Bool{Value: true, Valid: true}},
+		{name: "bool value pointer", val: &Bool{Value: true, Valid: true}, dst: new(*Bool), want: &Bool{Value: true, Valid: true}},
+		{name: "null bool", val: &Bool{}, dst: new(bool), want: false},
+		{name: "null bool pointer", val: &Bool{}, dst: new(*bool), want: (*bool)(nil)},
+		{name: "int to int", val: &Int{Value: 3, Valid: true}, dst: new(int), want: 3},
+		{name: "int to *int64", val: &Int{Value: 3, Valid: true}, dst: new(*int64), want: &i3},
+		{name: "long", val: &Long{Value: 9, Valid: true}, dst: new(int64), want: int64(9)},
+		{name: "long pointer", val: &Long{Value: 9, Valid: true}, dst: new(*int64), want: &i64},
+		{name: "null long to *int", val: &Long{}, dst: new(*int), want: (*int)(nil)},
+		{name: "real", val: &Real{Value: 1.5, Valid: true}, dst: new(float64), want: 1.5},
+		{name: "real pointer", val: &Real{Value: 1.5, Valid: true}, dst: new(*float64), want: &f64},
+		{name: "real to float32", val: &Real{Value: 1.5, Valid: true}, dst: new(float32), want: float32(1.5)},
+		{name: "string", val: &String{Value: "x", Valid: true}, dst: new(string), want: "x"},
+		{name: "string pointer", val: &String{Value: "x", Valid: true}, dst: new(*string), want: &s},
+		{name: "named string", val: &String{Value: "x", Valid: true}, dst: new(myString), want: myString("x")},
+		{name: "datetime", val: &DateTime{Value: now, Valid: true}, dst: new(time.Time), want: now},
+		{name: "timespan", val: &Timespan{Value: time.Second, Valid: true}, dst: new(time.Duration), want: time.Second},
+		{name: "guid", val: &GUID{Value: id, Valid: true}, dst: new(uuid.UUID), want: id},
+		{name: "decimal", val: &Decimal{Value: "1.25", Valid: true}, dst: new(string), want: "1.25"},
+		{name: "dynamic bytes", val: &Dynamic{Value: []byte(`[1]`), Valid: true}, dst: new([]byte), want: []byte(`[1]`)},
+		{name: "dynamic slice", val: &Dynamic{Value: []byte(`[1,2]`), Valid: true}, dst: new([]int), want: []int{1, 2}},
+		{name: "null dynamic map", val: &Dynamic{}, dst: new(map[string]int), want: map[string]int(nil)},
+		{name: "interface", val: &Long{Value: 9, Valid: true}, dst: new(interface{}), want: int64(9)},
+		{name: "null interface", val: &Long{}, dst: new(interface{}), want: nil},
+		{name: "value interface", val: &Long{Value: 9, Valid: true}, dst: new(Value), want: &Long{Value: 9, Valid: true}},
+		{name: "stringer", val: &Long{Value: 9, Valid: true}, dst: new(fmt.Stringer), want: &Long{Value: 9, Valid: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := reflect.ValueOf(test.dst).Elem()
+			if err := test.val.Convert(dst); err != nil {
+				t.Fatalf("Convert() returned error: %v", err)
+			}
+			if got := dst.Interface(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConvertDecimalBigFloat(t *testing.T) {
+	var f *big.Float
+	if err := (&Decimal{Value: "12345678901234567890.5", Valid: true}).Convert(reflect.ValueOf(&f).Elem()); err != nil {
+		t.Fatalf("Convert() returned error: %v", err)
+	}
+	if got := f.Text('f', 1); got != "12345678901234567890.5" {
+		t.Errorf("got %s, want 12345678901234567890.5", got)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		val  Value
+		dst  interface{}
+	}{
+		{name: "bool to string", val: &Bool{Value: true, Valid: true}, dst: new(string)},
+		{name: "long overflows int8", val: &Long{Value: math.MaxInt64, Valid: true}, dst: new(int8)},
+		{name: "real overflows float32", val: &Real{Value: math.MaxFloat64, Valid: true}, dst: new(float32)},
+		{name: "long to duration", val: &Long{Value: 1, Valid: true}, dst: new(time.Duration)},
+		{name: "real to int", val: &Real{Value: 1, Valid: true}, dst: new(int)},
+		{name: "dynamic mismatch", val: &Dynamic{Value: []byte(`{"a":1}`), Valid: true}, dst: new([]int)},
+		{name: "bad decimal", val: &Decimal{Value: "abc", Valid: true}, dst: new(big.Float)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.val.Convert(reflect.ValueOf(test.dst).Elem()); err == nil {
+				t.Errorf("Convert() succeeded, want error")
+			}
+		})
+	}
+}
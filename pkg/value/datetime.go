@@ -2,7 +2,10 @@ package value
 
 import (
 	"fmt"
+	"reflect"
 	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
 )
 
 type DateTime struct {
@@ -19,3 +22,59 @@ func (d DateTime) String() string {
 }
 
 func (DateTime) isKustoVal() {}
+
+// localLayout is the layout of datetimes sent without a time zone, which are in UTC.
+const localLayout = "2006-01-02T15:04:05.9999999"
+
+// Unmarshal unmarshals i into DateTime. i must be an ISO8601 formatted string with up to 7 fractional
+// digits, such as 2023-12-21T10:00:00.1234567Z, or nil. Strings without a time zone are parsed as UTC.
+func (d *DateTime) Unmarshal(i interface{}) error {
+	if i == nil {
+		d.Value = time.Time{}
+		d.Valid = false
+		return nil
+	}
+	str, ok := i.(string)
+	if !ok {
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'datetime' had value that was %T", i)
+	}
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		t, err = time.ParseInLocation(localLayout, str, time.UTC)
+	}
+	if err != nil {
+		return errors.ErrWrapf(err, "Column with type 'datetime' had value %q that could not be parsed", str)
+	}
+	d.Value = t
+	d.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a time.Time, a string or []byte in the RFC3339 format, or nil.
+func (d *DateTime) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return d.Unmarshal(nil)
+	case time.Time:
+		d.Value = v
+		d.Valid = true
+		return nil
+	}
+	if s, ok := scanText(src); ok {
+		return d.Unmarshal(s)
+	}
+	return scanError("datetime", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *DateTime) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(d, b)
+}
+
+// Convert DateTime into reflect value. v may be a time.Time, *time.Time, DateTime or *DateTime.
+func (d DateTime) Convert(v reflect.Value) error {
+	if convert(v, d.Valid, d.Value, d) {
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'datetime' could not be stored in %s", v.Type())
+}
@@ -1,9 +1,34 @@
 package value
 
-import "regexp"
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
 
 var DecRE = regexp.MustCompile(`^((\d+\.?\d*)|(\d*\.?\d+))$`) // Matches decimal numbers, with or without decimal dot, with optional parts missing.
 
+// decimalPrecision is the mantissa precision used to parse decimals into a big.Float, large enough to hold
+// the 34 significant digits of a Kusto decimal.
+const decimalPrecision = 128
+
+// ParseDecimal parses s as a base 10 decimal number, with an optional sign and exponent. Infinities are
+// rejected, as decimals have none.
+func ParseDecimal(s string) (*big.Float, error) {
+	f, _, err := big.ParseFloat(s, 10, decimalPrecision, big.ToNearestEven)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsInf() {
+		return nil, errors.ErrWrapf(errors.ErrInvalidType, "decimal %q is infinite", s)
+	}
+	return f, nil
+}
+
 type Decimal struct {
 	Value string
 	Valid bool
@@ -17,3 +42,79 @@ func (d Decimal) String() string {
 	}
 	return d.Value
 }
+
+// Unmarshal unmarshals i into Decimal. i must be a string or json.Number representing a decimal, or nil.
+// Kusto sends decimals as strings so that they keep their full precision.
+func (d *Decimal) Unmarshal(i interface{}) error {
+	if i == nil {
+		d.Value = ""
+		d.Valid = false
+		return nil
+	}
+
+	var str string
+	switch v := i.(type) {
+	case string:
+		str = v
+	case json.Number:
+		str = v.String()
+	default:
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'decimal' had value that was %T", i)
+	}
+
+	if _, err := ParseDecimal(str); err != nil {
+		return errors.ErrWrapf(err, "Column with type 'decimal' had value %q that is not a decimal", str)
+	}
+	d.Value = str
+	d.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a string or []byte holding a decimal, an int64, a float64, or nil.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return d.Unmarshal(nil)
+	case int64:
+		return d.Unmarshal(json.Number(strconv.FormatInt(v, 10)))
+	case float64:
+		return d.Unmarshal(json.Number(strconv.FormatFloat(v, 'f', -1, 64)))
+	}
+	if s, ok := scanText(src); ok {
+		return d.Unmarshal(s)
+	}
+	return scanError("decimal", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(d, b)
+}
+
+// Convert Decimal into reflect value. v may be a string, *string, Decimal or *Decimal,
+// or a big.Float or *big.Float holding the parsed value.
+func (d Decimal) Convert(v reflect.Value) error {
+	if convert(v, d.Valid, d.Value, d) {
+		return nil
+	}
+
+	switch v.Type() {
+	case reflect.TypeOf(big.Float{}), reflect.TypeOf(&big.Float{}):
+		if !d.Valid {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		f, _, err := big.ParseFloat(d.Value, 10, decimalPrecision, big.ToNearestEven)
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'decimal' had value %q that could not be parsed", d.Value)
+		}
+		if v.Kind() == reflect.Ptr {
+			v.Set(reflect.ValueOf(f))
+		} else {
+			v.Set(reflect.ValueOf(f).Elem())
+		}
+		return nil
+	}
+
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'decimal' could not be stored in %s", v.Type())
+}
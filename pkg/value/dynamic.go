@@ -1,5 +1,13 @@
 package value
 
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
 type Dynamic struct {
 	Value []byte
 
@@ -15,3 +23,78 @@ func (d Dynamic) String() string {
 
 	return string(d.Value)
 }
+
+// Unmarshal unmarshals i into Dynamic. i can be any decoded JSON value, including strings, which are stored
+// as JSON strings, or a []byte holding JSON, which is stored as-is.
+func (d *Dynamic) Unmarshal(i interface{}) error {
+	if i == nil {
+		d.Value = nil
+		d.Valid = false
+		return nil
+	}
+
+	switch v := i.(type) {
+	case []byte:
+		if !json.Valid(v) {
+			return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'dynamic' had value that is not valid JSON")
+		}
+		d.Value = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'dynamic' had value that could not be marshaled")
+		}
+		d.Value = b
+	}
+	d.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a []byte or string holding JSON, which is stored as-is, or nil.
+// Strings that are not JSON and other values are stored as their JSON encoding.
+func (d *Dynamic) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		// database/sql may reuse the memory of v once Scan returns.
+		return d.Unmarshal(append([]byte(nil), v...))
+	case string:
+		if json.Valid([]byte(v)) {
+			return d.Unmarshal([]byte(v))
+		}
+	}
+	return d.Unmarshal(src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The JSON is stored as-is.
+func (d *Dynamic) UnmarshalJSON(b []byte) error {
+	if string(bytes.TrimSpace(b)) == "null" {
+		return d.Unmarshal(nil)
+	}
+	if !json.Valid(b) {
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'dynamic' had value that is not valid JSON")
+	}
+	d.Value = append([]byte(nil), b...)
+	d.Valid = true
+	return nil
+}
+
+// Convert Dynamic into reflect value. v may be a []byte holding the JSON, Dynamic, *Dynamic, or any type
+// the JSON can be unmarshaled into, such as a map, slice, struct or a pointer to one. v is set to its zero
+// value when the Dynamic is not valid.
+func (d Dynamic) Convert(v reflect.Value) error {
+	if convert(v, d.Valid, d.Value, d) {
+		return nil
+	}
+
+	if !d.Valid {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	p := reflect.New(v.Type())
+	if err := json.Unmarshal(d.Value, p.Interface()); err != nil {
+		return errors.ErrWrapf(err, "Column with type 'dynamic' could not be stored in %s", v.Type())
+	}
+	v.Set(p.Elem())
+	return nil
+}
@@ -1,6 +1,11 @@
 package value
 
-import "github.com/google/uuid"
+import (
+	"reflect"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/google/uuid"
+)
 
 type GUID struct {
 	Value uuid.UUID
@@ -16,3 +21,63 @@ func (g GUID) String() string {
 	}
 	return g.Value.String()
 }
+
+// Unmarshal unmarshals i into GUID. i must be a string representing a UUID or nil.
+func (g *GUID) Unmarshal(i interface{}) error {
+	if i == nil {
+		g.Value = uuid.UUID{}
+		g.Valid = false
+		return nil
+	}
+	str, ok := i.(string)
+	if !ok {
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'guid' had value that was %T", i)
+	}
+	u, err := uuid.Parse(str)
+	if err != nil {
+		return errors.ErrWrapf(err, "Column with type 'guid' had value %q that could not be parsed", str)
+	}
+	g.Value = u
+	g.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a uuid.UUID, a string or []byte holding a guid, a []byte of its
+// 16 bytes, or nil.
+func (g *GUID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return g.Unmarshal(nil)
+	case uuid.UUID:
+		g.Value = v
+		g.Valid = true
+		return nil
+	case []byte:
+		if len(v) == 16 {
+			u, err := uuid.FromBytes(v)
+			if err != nil {
+				return errors.ErrWrapf(err, "value cannot be scanned into a value of type 'guid'")
+			}
+			g.Value = u
+			g.Valid = true
+			return nil
+		}
+	}
+	if s, ok := scanText(src); ok {
+		return g.Unmarshal(s)
+	}
+	return scanError("guid", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (g *GUID) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(g, b)
+}
+
+// Convert GUID into reflect value. v may be a uuid.UUID, *uuid.UUID, GUID or *GUID.
+func (g GUID) Convert(v reflect.Value) error {
+	if convert(v, g.Valid, g.Value, g) {
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'guid' could not be stored in %s", v.Type())
+}
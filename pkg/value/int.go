@@ -1,6 +1,13 @@
 package value
 
-import "strconv"
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
 
 type Int struct {
 	Value int32
@@ -15,3 +22,65 @@ func (in Int) String() string {
 	}
 	return strconv.Itoa(int(in.Value))
 }
+
+// Unmarshal unmarshals i into Int. i must be a json.Number or float64 holding an int32, or nil.
+func (in *Int) Unmarshal(i interface{}) error {
+	if i == nil {
+		in.Value = 0
+		in.Valid = false
+		return nil
+	}
+
+	switch v := i.(type) {
+	case json.Number:
+		n, err := strconv.ParseInt(v.String(), 10, 32)
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'int' had value %s that is not an int32", v)
+		}
+		in.Value = int32(n)
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt32 || v < math.MinInt32 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'int' had value %v that is not an int32", v)
+		}
+		in.Value = int32(v)
+	default:
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'int' had value that was %T", i)
+	}
+	in.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be an int64 or float64 holding an int32, a string or []byte holding
+// one, or nil.
+func (in *Int) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil, float64:
+		return in.Unmarshal(v)
+	case int64:
+		return in.Unmarshal(json.Number(strconv.FormatInt(v, 10)))
+	}
+	if s, ok := scanText(src); ok {
+		return in.Unmarshal(json.Number(s))
+	}
+	return scanError("int", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (in *Int) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(in, b)
+}
+
+// Convert Int into reflect value. v may be an int32, *int32, Int or *Int,
+// or any signed integer type or a pointer to one, as long as the value does not overflow it.
+func (in Int) Convert(v reflect.Value) error {
+	if convert(v, in.Valid, in.Value, in) {
+		return nil
+	}
+	if ok, err := convertInt(v, in.Valid, int64(in.Value)); ok {
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'int' could not be stored in %s", v.Type())
+		}
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'int' could not be stored in %s", v.Type())
+}
@@ -1,6 +1,13 @@
 package value
 
-import "strconv"
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
 
 type Long struct {
 	Value int64
@@ -14,5 +21,70 @@ func (l Long) String() string {
 	if !l.Valid {
 		return ""
 	}
-	return strconv.Itoa(int(l.Value))
+	return strconv.FormatInt(l.Value, 10)
+}
+
+// Unmarshal unmarshals i into Long. i must be a json.Number or float64 holding an int64, or nil.
+// Values that do not fit in a float64 without losing precision must be passed as a json.Number.
+func (l *Long) Unmarshal(i interface{}) error {
+	if i == nil {
+		l.Value = 0
+		l.Valid = false
+		return nil
+	}
+
+	switch v := i.(type) {
+	case json.Number:
+		n, err := strconv.ParseInt(v.String(), 10, 64)
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'long' had value %s that is not an int64", v)
+		}
+		l.Value = n
+	case float64:
+		if v != math.Trunc(v) || v >= math.MaxInt64 || v < math.MinInt64 {
+			return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'long' had value %v that is not an int64", v)
+		}
+		l.Value = int64(v)
+	default:
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'long' had value that was %T", i)
+	}
+	l.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be an int64, a float64 holding an int64, a string or []byte holding
+// one, or nil.
+func (l *Long) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil, float64:
+		return l.Unmarshal(v)
+	case int64:
+		l.Value = v
+		l.Valid = true
+		return nil
+	}
+	if s, ok := scanText(src); ok {
+		return l.Unmarshal(json.Number(s))
+	}
+	return scanError("long", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Long) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(l, b)
+}
+
+// Convert Long into reflect value. v may be an int64, *int64, Long or *Long,
+// or any signed integer type or a pointer to one, as long as the value does not overflow it.
+func (l Long) Convert(v reflect.Value) error {
+	if convert(v, l.Valid, l.Value, l) {
+		return nil
+	}
+	if ok, err := convertInt(v, l.Valid, l.Value); ok {
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'long' could not be stored in %s", v.Type())
+		}
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'long' could not be stored in %s", v.Type())
 }
@@ -1,6 +1,13 @@
 package value
 
-import "strconv"
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
 
 type Real struct {
 	Value float64
@@ -17,3 +24,77 @@ func (r Real) String() string {
 	}
 	return strconv.FormatFloat(r.Value, 'e', -1, 64)
 }
+
+// Unmarshal unmarshals i into Real. i must be a json.Number, a float64, one of the "NaN", "Infinity" and
+// "-Infinity" strings Kusto uses for special values, or nil.
+func (r *Real) Unmarshal(i interface{}) error {
+	if i == nil {
+		r.Value = 0
+		r.Valid = false
+		return nil
+	}
+
+	switch v := i.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'real' had value %s that is not a float64", v)
+		}
+		r.Value = f
+	case float64:
+		r.Value = v
+	case string:
+		switch v {
+		case "NaN":
+			r.Value = math.NaN()
+		case "Infinity":
+			r.Value = math.Inf(1)
+		case "-Infinity":
+			r.Value = math.Inf(-1)
+		default:
+			return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'real' had value %q that is not a float64", v)
+		}
+	default:
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'real' had value that was %T", i)
+	}
+	r.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a float64, an int64, a string or []byte holding a number, NaN,
+// Infinity or -Infinity, or nil.
+func (r *Real) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil, float64:
+		return r.Unmarshal(v)
+	case int64:
+		return r.Unmarshal(float64(v))
+	}
+	if s, ok := scanText(src); ok {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return r.Unmarshal(f)
+		}
+		return r.Unmarshal(s)
+	}
+	return scanError("real", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Real) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(r, b)
+}
+
+// Convert Real into reflect value. v may be a float64, *float64, Real or *Real,
+// or any float type or a pointer to one, as long as the value does not overflow it.
+func (r Real) Convert(v reflect.Value) error {
+	if convert(v, r.Valid, r.Value, r) {
+		return nil
+	}
+	if ok, err := convertFloat(v, r.Valid, r.Value); ok {
+		if err != nil {
+			return errors.ErrWrapf(err, "Column with type 'real' could not be stored in %s", v.Type())
+		}
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'real' could not be stored in %s", v.Type())
+}
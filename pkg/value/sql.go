@@ -0,0 +1,77 @@
+package value
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/google/uuid"
+)
+
+var (
+	_ sql.Scanner = (*Bool)(nil)
+	_ sql.Scanner = (*DateTime)(nil)
+	_ sql.Scanner = (*Decimal)(nil)
+	_ sql.Scanner = (*Dynamic)(nil)
+	_ sql.Scanner = (*GUID)(nil)
+	_ sql.Scanner = (*Int)(nil)
+	_ sql.Scanner = (*Long)(nil)
+	_ sql.Scanner = (*Real)(nil)
+	_ sql.Scanner = (*String)(nil)
+	_ sql.Scanner = (*Timespan)(nil)
+
+	_ driver.Valuer = SQLValue{}
+)
+
+// SQLValue adapts a Value to driver.Valuer, so that it can be passed as an argument to database/sql. The
+// value types cannot implement driver.Valuer themselves, as its Value method would conflict with their
+// Value field.
+//
+//	db.QueryContext(ctx, "T | where Id == id", sql.Named("id", value.SQL(&id)))
+type SQLValue struct {
+	Kusto Value
+}
+
+// SQL returns v as a driver.Valuer.
+func SQL(v Value) SQLValue {
+	return SQLValue{Kusto: v}
+}
+
+// Value implements driver.Valuer. It returns nil when the value is not valid, and otherwise its native Go
+// value, with ints widened to int64, guids as strings, dynamic values as []byte holding their JSON, and
+// timespans as time.Duration. Drivers without support for time.Duration need timespans converted first.
+func (s SQLValue) Value() (driver.Value, error) {
+	if s.Kusto == nil {
+		return nil, nil
+	}
+
+	var i interface{}
+	if err := s.Kusto.Convert(reflect.ValueOf(&i).Elem()); err != nil {
+		return nil, err
+	}
+
+	switch n := i.(type) {
+	case int32:
+		return int64(n), nil
+	case uuid.UUID:
+		return n.String(), nil
+	}
+	return i, nil
+}
+
+// scanText returns src as a string when it is a string or a []byte, as database/sql passes text to Scan.
+func scanText(src interface{}) (string, bool) {
+	switch v := src.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// scanError returns the error of Scan when src cannot be stored in a value of type t.
+func scanError(t string, src interface{}) error {
+	return errors.ErrWrapf(errors.ErrInvalidType, "%T cannot be scanned into a value of type '%s'", src, t)
+}
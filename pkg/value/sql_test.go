@@ -0,0 +1,141 @@
+package value
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestScan(t *testing.T) {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	now := time.Date(2023, 12, 21, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		dst  sql.Scanner
+		src  interface{}
+		want interface{}
+	}{
+		{name: "bool", dst: &Bool{}, src: true, want: &Bool{Value: true, Valid: true}},
+		{name: "bool int64", dst: &Bool{}, src: int64(1), want: &Bool{Value: true, Valid: true}},
+		{name: "bool text", dst: &Bool{}, src: []byte("false"), want: &Bool{Value: false, Valid: true}},
+		{name: "bool nil", dst: &Bool{Value: true, Valid: true}, src: nil, want: &Bool{}},
+		{name: "int", dst: &Int{}, src: int64(3), want: &Int{Value: 3, Valid: true}},
+		{name: "int text", dst: &Int{}, src: "-3", want: &Int{Value: -3, Valid: true}},
+		{name: "long", dst: &Long{}, src: int64(math.MaxInt64), want: &Long{Value: math.MaxInt64, Valid: true}},
+		{name: "long float64", dst: &Long{}, src: float64(2), want: &Long{Value: 2, Valid: true}},
+		{name: "long text", dst: &Long{}, src: []byte("9"), want: &Long{Value: 9, Valid: true}},
+		{name: "long nil", dst: &Long{Value: 1, Valid: true}, src: nil, want: &Long{}},
+		{name: "real", dst: &Real{}, src: 1.5, want: &Real{Value: 1.5, Valid: true}},
+		{name: "real int64", dst: &Real{}, src: int64(2), want: &Real{Value: 2, Valid: true}},
+		{name: "real text", dst: &Real{}, src: "Infinity", want: &Real{Value: math.Inf(1), Valid: true}},
+		{name: "string", dst: &String{}, src: "x", want: &String{Value: "x", Valid: true}},
+		{name: "string bytes", dst: &String{}, src: []byte("x"), want: &String{Value: "x", Valid: true}},
+		{name: "string nil", dst: &String{Value: "x", Valid: true}, src: nil, want: &String{}},
+		{name: "datetime", dst: &DateTime{}, src: now, want: &DateTime{Value: now, Valid: true}},
+		{name: "datetime text", dst: &DateTime{}, src: "2023-12-21T00:00:00Z", want: &DateTime{Value: now, Valid: true}},
+		{name: "timespan", dst: &Timespan{}, src: time.Minute, want: &Timespan{Value: time.Minute, Valid: true}},
+		{name: "timespan int64", dst: &Timespan{}, src: int64(time.Second), want: &Timespan{Value: time.Second, Valid: true}},
+		{name: "timespan text", dst: &Timespan{}, src: "1.00:00:00", want: &Timespan{Value: 24 * time.Hour, Valid: true}},
+		{name: "guid", dst: &GUID{}, src: id, want: &GUID{Value: id, Valid: true}},
+		{name: "guid text", dst: &GUID{}, src: id.String(), want: &GUID{Value: id, Valid: true}},
+		{name: "guid bytes", dst: &GUID{}, src: id[:], want: &GUID{Value: id, Valid: true}},
+		{name: "dynamic", dst: &Dynamic{}, src: []byte(`{"a":1}`), want: &Dynamic{Value: []byte(`{"a":1}`), Valid: true}},
+		{name: "dynamic string", dst: &Dynamic{}, src: "x", want: &Dynamic{Value: []byte(`"x"`), Valid: true}},
+		{name: "dynamic nil", dst: &Dynamic{Value: []byte("1"), Valid: true}, src: nil, want: &Dynamic{}},
+		{name: "decimal", dst: &Decimal{}, src: []byte("1.25"), want: &Decimal{Value: "1.25", Valid: true}},
+		{name: "decimal int64", dst: &Decimal{}, src: int64(3), want: &Decimal{Value: "3", Valid: true}},
+		{name: "decimal float64", dst: &Decimal{}, src: 0.5, want: &Decimal{Value: "0.5", Valid: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.dst.Scan(test.src); err != nil {
+				t.Fatalf("Scan(%v) returned error: %v", test.src, err)
+			}
+			if !reflect.DeepEqual(test.dst, test.want) {
+				t.Errorf("Scan(%v) = %+v, want %+v", test.src, test.dst, test.want)
+			}
+		})
+	}
+}
+
+func TestScanCopiesBytes(t *testing.T) {
+	b := []byte(`[1]`)
+	var d Dynamic
+	if err := d.Scan(b); err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+	b[1] = '2'
+	if string(d.Value) != "[1]" {
+		t.Errorf("Dynamic holds %s after the scanned bytes changed, want [1]", d.Value)
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  sql.Scanner
+		src  interface{}
+	}{
+		{name: "bool", dst: &Bool{}, src: "maybe"},
+		{name: "int overflow", dst: &Int{}, src: int64(math.MaxInt32 + 1)},
+		{name: "long text", dst: &Long{}, src: "x"},
+		{name: "long fraction", dst: &Long{}, src: 1.5},
+		{name: "real", dst: &Real{}, src: "x"},
+		{name: "string", dst: &String{}, src: int64(1)},
+		{name: "datetime", dst: &DateTime{}, src: "yesterday"},
+		{name: "timespan", dst: &Timespan{}, src: 1.5},
+		{name: "guid", dst: &GUID{}, src: "x"},
+		{name: "dynamic", dst: &Dynamic{}, src: []byte("{")},
+		{name: "decimal", dst: &Decimal{}, src: "x"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.dst.Scan(test.src); err == nil {
+				t.Errorf("Scan(%v) returned no error", test.src)
+			}
+		})
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	now := time.Date(2023, 12, 21, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    Value
+		want interface{}
+	}{
+		{name: "bool", v: &Bool{Value: true, Valid: true}, want: true},
+		{name: "int", v: &Int{Value: 3, Valid: true}, want: int64(3)},
+		{name: "long", v: &Long{Value: 4, Valid: true}, want: int64(4)},
+		{name: "real", v: &Real{Value: 1.5, Valid: true}, want: 1.5},
+		{name: "string", v: &String{Value: "x", Valid: true}, want: "x"},
+		{name: "datetime", v: &DateTime{Value: now, Valid: true}, want: now},
+		{name: "timespan", v: &Timespan{Value: time.Minute, Valid: true}, want: time.Minute},
+		{name: "guid", v: &GUID{Value: id, Valid: true}, want: id.String()},
+		{name: "dynamic", v: &Dynamic{Value: []byte(`{"a":1}`), Valid: true}, want: []byte(`{"a":1}`)},
+		{name: "decimal", v: &Decimal{Value: "1.25", Valid: true}, want: "1.25"},
+		{name: "null", v: &Long{}, want: nil},
+		{name: "nil", v: nil, want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := SQL(test.v).Value()
+			if err != nil {
+				t.Fatalf("Value() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Value() = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
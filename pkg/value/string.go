@@ -1,5 +1,11 @@
 package value
 
+import (
+	"reflect"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+)
+
 type String struct {
 	Value string
 	Valid bool
@@ -13,3 +19,43 @@ func (s String) String() string {
 	}
 	return s.Value
 }
+
+// Unmarshal unmarshals i into String. i must be a string or nil.
+func (s *String) Unmarshal(i interface{}) error {
+	if i == nil {
+		s.Value = ""
+		s.Valid = false
+		return nil
+	}
+	v, ok := i.(string)
+	if !ok {
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'string' had value that was %T", i)
+	}
+	s.Value = v
+	s.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a string, a []byte, or nil.
+func (s *String) Scan(src interface{}) error {
+	if src == nil {
+		return s.Unmarshal(nil)
+	}
+	if str, ok := scanText(src); ok {
+		return s.Unmarshal(str)
+	}
+	return scanError("string", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *String) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(s, b)
+}
+
+// Convert String into reflect value. v may be a string, *string, String or *String.
+func (s String) Convert(v reflect.Value) error {
+	if convert(v, s.Valid, s.Value, s) {
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'string' could not be stored in %s", v.Type())
+}
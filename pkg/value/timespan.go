@@ -2,8 +2,12 @@ package value
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
 )
 
 const tick = 100 * time.Nanosecond
@@ -23,6 +27,128 @@ func (t Timespan) String() string {
 	return t.Value.String()
 }
 
+// Unmarshal unmarshals i into Timespan. i must be a string in the [-][d.]hh:mm:ss[.fffffff] format or nil.
+func (t *Timespan) Unmarshal(i interface{}) error {
+	if i == nil {
+		t.Value = 0
+		t.Valid = false
+		return nil
+	}
+	str, ok := i.(string)
+	if !ok {
+		return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'timespan' had value that was %T", i)
+	}
+	d, err := parseTimespan(str)
+	if err != nil {
+		return errors.ErrWrapf(err, "Column with type 'timespan' had value %q that could not be parsed", str)
+	}
+	t.Value = d
+	t.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner. src may be a time.Duration, an int64 holding nanoseconds, a string or []byte
+// in the [-][d.]hh:mm:ss[.fffffff] format, or nil.
+func (t *Timespan) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return t.Unmarshal(nil)
+	case time.Duration:
+		t.Value = v
+		t.Valid = true
+		return nil
+	case int64:
+		t.Value = time.Duration(v)
+		t.Valid = true
+		return nil
+	}
+	if s, ok := scanText(src); ok {
+		return t.Unmarshal(s)
+	}
+	return scanError("timespan", src)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Timespan) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(t, b)
+}
+
+// Convert Timespan into reflect value. v may be a time.Duration, *time.Duration, Timespan or *Timespan.
+func (t Timespan) Convert(v reflect.Value) error {
+	if convert(v, t.Valid, t.Value, t) {
+		return nil
+	}
+	return errors.ErrWrapf(errors.ErrInvalidType, "Column with type 'timespan' could not be stored in %s", v.Type())
+}
+
+// parseTimespan parses the [-][d.]hh:mm:ss[.fffffff] string representation Kusto uses for timespans.
+func parseTimespan(s string) (time.Duration, error) {
+	const day = 24 * time.Hour
+
+	str := s
+	negative := strings.HasPrefix(str, "-")
+	if negative {
+		str = str[1:]
+	}
+
+	parts := strings.Split(str, ":")
+	if len(parts) != 3 {
+		return 0, errors.ErrWrapf(errors.ErrInvalidType, "timespan must be in [-][d.]hh:mm:ss[.fffffff] format")
+	}
+
+	var val time.Duration
+
+	// The hours part may be preceded by the number of days.
+	hours := parts[0]
+	if idx := strings.Index(hours, "."); idx >= 0 {
+		days, err := strconv.ParseInt(hours[:idx], 10, 64)
+		if err != nil {
+			return 0, errors.ErrWrapf(err, "invalid days")
+		}
+		val += time.Duration(days) * day
+		hours = hours[idx+1:]
+	}
+
+	h, err := strconv.ParseInt(hours, 10, 64)
+	if err != nil {
+		return 0, errors.ErrWrapf(err, "invalid hours")
+	}
+	val += time.Duration(h) * time.Hour
+
+	m, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, errors.ErrWrapf(err, "invalid minutes")
+	}
+	val += time.Duration(m) * time.Minute
+
+	// The seconds part may be followed by up to 7 digits of ticks.
+	seconds := parts[2]
+	if idx := strings.Index(seconds, "."); idx >= 0 {
+		frac := seconds[idx+1:]
+		if len(frac) == 0 || len(frac) > 7 {
+			return 0, errors.ErrWrapf(errors.ErrInvalidType, "fractional seconds must have between 1 and 7 digits")
+		}
+		ticks, err := strconv.ParseInt(frac+strings.Repeat("0", 7-len(frac)), 10, 64)
+		if err != nil {
+			return 0, errors.ErrWrapf(err, "invalid fractional seconds")
+		}
+		val += time.Duration(ticks) * tick
+		seconds = seconds[:idx]
+	}
+
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return 0, errors.ErrWrapf(err, "invalid seconds")
+	}
+	val += time.Duration(sec) * time.Second
+
+	if negative {
+		val = -val
+	}
+
+	return val, nil
+}
+
 func (t Timespan) Marshal() string {
 	const (
 		day = 24 * time.Hour
@@ -62,19 +188,12 @@ func (t Timespan) Marshal() string {
 	val = val - (seconds * time.Second)
 	sb.WriteString(fmt.Sprintf("%02d:%02d:%02d", int(hours), int(minutes), int(seconds)))
 
-	// Add our sub-second string representation that is proceeded with a ".".
-	milliseconds := val / time.Millisecond
-	val = val - (milliseconds * time.Millisecond)
+	// Add our sub-second string representation of up to 7 digits that is proceeded with a ".",
+	// removing any trailing 0's.
 	ticks := val / tick
-	if milliseconds > 0 || ticks > 0 {
-		sb.WriteString(fmt.Sprintf(".%03d%d", milliseconds, ticks))
-	}
-
-	// Remove any trailing 0's.
-	str := strings.TrimRight(sb.String(), "0")
-	if strings.HasSuffix(str, ":") {
-		str = str + "00"
+	if ticks > 0 {
+		sb.WriteString(strings.TrimRight(fmt.Sprintf(".%07d", int(ticks)), "0"))
 	}
 
-	return str
+	return sb.String()
 }
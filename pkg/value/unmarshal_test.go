@@ -0,0 +1,133 @@
+package value
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalJSON(t *testing.T) {
+	var row struct {
+		Long     Long
+		Int      Int
+		Real     Real
+		NaN      Real
+		Decimal  Decimal
+		DecNum   Decimal
+		DateTime DateTime
+		Local    DateTime
+		Timespan Timespan
+		Negative Timespan
+		Dynamic  Dynamic
+		Null     Dynamic
+		GUID     GUID
+		Bool     Bool
+		String   String
+	}
+
+	body := `{
+		"Long": 9223372036854775807,
+		"Int": -2147483648,
+		"Real": 1.5,
+		"NaN": "NaN",
+		"Decimal": "-12345678901234567890.123456789",
+		"DecNum": 1.25,
+		"DateTime": "2023-12-21T10:00:00.1234567Z",
+		"Local": "2023-12-21T10:00:00.1234567",
+		"Timespan": "1.02:03:04.0000001",
+		"Negative": "-00:00:10",
+		"Dynamic": {"a": [1, 2]},
+		"Null": null,
+		"GUID": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"Bool": true,
+		"String": "s"
+	}`
+	if err := json.Unmarshal([]byte(body), &row); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	if row.Long.Value != math.MaxInt64 {
+		t.Errorf("Long = %d, want %d", row.Long.Value, int64(math.MaxInt64))
+	}
+	if row.Int.Value != math.MinInt32 {
+		t.Errorf("Int = %d, want %d", row.Int.Value, math.MinInt32)
+	}
+	if row.Real.Value != 1.5 || !math.IsNaN(row.NaN.Value) {
+		t.Errorf("Real = %v, NaN = %v", row.Real.Value, row.NaN.Value)
+	}
+	if row.Decimal.Value != "-12345678901234567890.123456789" || row.DecNum.Value != "1.25" {
+		t.Errorf("Decimal = %q, DecNum = %q", row.Decimal.Value, row.DecNum.Value)
+	}
+	want := time.Date(2023, 12, 21, 10, 0, 0, 123456700, time.UTC)
+	if !row.DateTime.Value.Equal(want) || !row.Local.Value.Equal(want) {
+		t.Errorf("DateTime = %v, Local = %v, want %v", row.DateTime.Value, row.Local.Value, want)
+	}
+	if got := row.Timespan.Value; got != 26*time.Hour+3*time.Minute+4*time.Second+100 {
+		t.Errorf("Timespan = %v", got)
+	}
+	if row.Negative.Value != -10*time.Second {
+		t.Errorf("Negative = %v, want -10s", row.Negative.Value)
+	}
+	if string(row.Dynamic.Value) != `{"a": [1, 2]}` || row.Null.Valid {
+		t.Errorf("Dynamic = %s, Null = %+v", row.Dynamic.Value, row.Null)
+	}
+	if !row.GUID.Valid || !row.Bool.Value || row.String.Value != "s" {
+		t.Errorf("GUID = %+v, Bool = %+v, String = %+v", row.GUID, row.Bool, row.String)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		val  Value
+		in   interface{}
+	}{
+		{name: "int overflow", val: &Int{}, in: json.Number("2147483648")},
+		{name: "long overflow", val: &Long{}, in: json.Number("9223372036854775808")},
+		{name: "long fraction", val: &Long{}, in: 1.5},
+		{name: "real string", val: &Real{}, in: "abc"},
+		{name: "decimal", val: &Decimal{}, in: "1.2.3"},
+		{name: "decimal hex", val: &Decimal{}, in: "0x10"},
+		{name: "decimal infinite", val: &Decimal{}, in: "Inf"},
+		{name: "datetime", val: &DateTime{}, in: "yesterday"},
+		{name: "timespan", val: &Timespan{}, in: "10:00"},
+		{name: "timespan fraction", val: &Timespan{}, in: "00:00:00.12345678"},
+		{name: "guid", val: &GUID{}, in: "abc"},
+		{name: "bool", val: &Bool{}, in: "true"},
+		{name: "string", val: &String{}, in: 1.0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.val.Unmarshal(test.in); err == nil {
+				t.Errorf("Unmarshal(%v) succeeded, want error", test.in)
+			}
+		})
+	}
+}
+
+func TestTimespanRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{0, 10 * time.Second, -90 * time.Minute, 49*time.Hour + 100, time.Millisecond + 200} {
+		str := Timespan{Value: d, Valid: true}.Marshal()
+		got := Timespan{}
+		if err := got.Unmarshal(str); err != nil {
+			t.Fatalf("Unmarshal(%q) returned error: %v", str, err)
+		}
+		if got.Value != d {
+			t.Errorf("Unmarshal(Marshal(%v)) = %v via %q", d, got.Value, str)
+		}
+	}
+}
+
+func TestDynamicUnmarshalString(t *testing.T) {
+	for in, want := range map[string]string{"123": `"123"`, "true": `"true"`, "null": `"null"`, "s": `"s"`} {
+		var d Dynamic
+		if err := d.Unmarshal(in); err != nil {
+			t.Fatalf("Unmarshal(%q) returned error: %v", in, err)
+		}
+		if !d.Valid || string(d.Value) != want {
+			t.Errorf("Unmarshal(%q) = %+v, want %s", in, d, want)
+		}
+	}
+}
@@ -1,16 +1,204 @@
 // Package value provides an interface and a type for handling Kusto values.
 package value
 
-import "reflect"
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/crodriguezde/go-kusto/pkg/errors"
+	"github.com/crodriguezde/go-kusto/pkg/types"
+)
 
 // Kusto is an interface that represents a Kusto value.
 // It provides methods for checking if a value is a Kusto value,
 // converting it to a string, and converting it from a reflect.Value.
+//
+// Convert stores the value in a Go variable. A value that is not Valid is stored as the zero value of
+// a native type, a nil pointer or a nil interface, and keeps Valid false when stored as a value type.
 type Value interface {
 	isKustoVal()                   // Checks if the value is a Kusto value
 	String() string                // Converts the Kusto value to a string
-	Convert(v reflect.Value) error // Converts a reflect.Value to a Kusto value
+	Convert(v reflect.Value) error // Stores the Kusto value in a reflect.Value
+	Unmarshal(i interface{}) error // Sets the Kusto value from its decoded JSON representation
 }
 
 // Values is a slice of Kusto values.
 type Values []Value
+
+var (
+	_ Value = (*Bool)(nil)
+	_ Value = (*DateTime)(nil)
+	_ Value = (*Decimal)(nil)
+	_ Value = (*Dynamic)(nil)
+	_ Value = (*GUID)(nil)
+	_ Value = (*Int)(nil)
+	_ Value = (*Long)(nil)
+	_ Value = (*Real)(nil)
+	_ Value = (*String)(nil)
+	_ Value = (*Timespan)(nil)
+)
+
+// New returns a new, unset Value for the given column type.
+func New(t types.Column) (Value, error) {
+	switch t {
+	case types.Bool:
+		return &Bool{}, nil
+	case types.DateTime:
+		return &DateTime{}, nil
+	case types.Dynamic:
+		return &Dynamic{}, nil
+	case types.GUID:
+		return &GUID{}, nil
+	case types.Int:
+		return &Int{}, nil
+	case types.Long:
+		return &Long{}, nil
+	case types.Real:
+		return &Real{}, nil
+	case types.String:
+		return &String{}, nil
+	case types.Timespan:
+		return &Timespan{}, nil
+	case types.Decimal:
+		return &Decimal{}, nil
+	}
+	return nil, errors.ErrWrapf(errors.ErrInvalidType, "unknown column type %q", t)
+}
+
+// unmarshalJSON decodes b, keeping numbers as json.Number so that they do not lose precision, and passes
+// the result to v.Unmarshal.
+func unmarshalJSON(v Value, b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var i interface{}
+	if err := dec.Decode(&i); err != nil {
+		return errors.ErrWrapf(err, "failed to decode %s", string(b))
+	}
+	return v.Unmarshal(i)
+}
+
+// convert stores a value in v, which may be of the native Go type of the value, a pointer to it, the
+// Kusto value type kv, a pointer to it, or an interface either of them implements. native is the Go value
+// held by kv, and valid whether kv was set. A nil pointer or interface is stored when kv is not valid.
+// It returns false if v is none of the supported types.
+func convert(v reflect.Value, valid bool, native interface{}, kv interface{}) bool {
+	t := v.Type()
+	nt := reflect.TypeOf(native)
+	kt := reflect.TypeOf(kv)
+
+	switch {
+	case t == kt:
+		v.Set(reflect.ValueOf(kv))
+		return true
+	case t == reflect.PtrTo(kt):
+		p := reflect.New(kt)
+		p.Elem().Set(reflect.ValueOf(kv))
+		v.Set(p)
+		return true
+	case sameType(t, nt):
+		v.Set(reflect.ValueOf(native).Convert(t))
+		return true
+	case t.Kind() == reflect.Ptr && sameType(t.Elem(), nt):
+		if !valid {
+			v.Set(reflect.Zero(t))
+			return true
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(reflect.ValueOf(native).Convert(t.Elem()))
+		v.Set(p)
+		return true
+	case t.Kind() == reflect.Interface && t.NumMethod() == 0:
+		if !valid {
+			v.Set(reflect.Zero(t))
+			return true
+		}
+		v.Set(reflect.ValueOf(native))
+		return true
+	case t.Kind() == reflect.Interface && reflect.PtrTo(kt).Implements(t):
+		p := reflect.New(kt)
+		p.Elem().Set(reflect.ValueOf(kv))
+		v.Set(p)
+		return true
+	case t.Kind() == reflect.Interface && kt.Implements(t):
+		v.Set(reflect.ValueOf(kv))
+		return true
+	}
+	return false
+}
+
+// convertInt stores the integer i in v, which may be of any signed integer kind or a pointer to one.
+// It returns false if v is none of those, and an error if i overflows v.
+func convertInt(v reflect.Value, valid bool, i int64) (bool, error) {
+	return convertNumber(v, valid, func(e reflect.Value) (bool, error) {
+		switch e.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if e.Type() == reflect.TypeOf(time.Duration(0)) {
+				return false, nil
+			}
+			if e.OverflowInt(i) {
+				return true, errors.ErrWrapf(errors.ErrInvalidType, "value %d overflows %s", i, e.Type())
+			}
+			e.SetInt(i)
+			return true, nil
+		}
+		return false, nil
+	})
+}
+
+// convertFloat stores the float f in v, which may be of any float kind or a pointer to one.
+// It returns false if v is none of those, and an error if f overflows v.
+func convertFloat(v reflect.Value, valid bool, f float64) (bool, error) {
+	return convertNumber(v, valid, func(e reflect.Value) (bool, error) {
+		switch e.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if e.OverflowFloat(f) {
+				return true, errors.ErrWrapf(errors.ErrInvalidType, "value %v overflows %s", f, e.Type())
+			}
+			e.SetFloat(f)
+			return true, nil
+		}
+		return false, nil
+	})
+}
+
+// convertNumber calls set with v, or with a newly allocated value when v is a pointer. A nil pointer is
+// stored when the value is not valid.
+func convertNumber(v reflect.Value, valid bool, set func(e reflect.Value) (bool, error)) (bool, error) {
+	if v.Kind() != reflect.Ptr {
+		return set(v)
+	}
+
+	p := reflect.New(v.Type().Elem())
+	ok, err := set(p.Elem())
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	if !valid {
+		v.Set(reflect.Zero(v.Type()))
+		return true, nil
+	}
+	v.Set(p)
+	return true, nil
+}
+
+// sameType reports whether t can hold a native value of type nt. Named types, such as type MyBool bool,
+// can hold values of the builtin scalar type they are based on, with the exception of time.Duration that
+// only holds timespans.
+func sameType(t, nt reflect.Type) bool {
+	if t == nt {
+		return true
+	}
+	if nt.PkgPath() != "" || t.Kind() != nt.Kind() || t == reflect.TypeOf(time.Duration(0)) {
+		return false
+	}
+
+	switch nt.Kind() {
+	case reflect.Bool, reflect.Int32, reflect.Int64, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}